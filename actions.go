@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	playKey = key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "play now"),
+	)
+	openWebKey = key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open in browser"),
+	)
+	openAppKey = key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "open in Spotify"),
+	)
+	copyURLKey = key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy URL"),
+	)
+	copyURIKey = key.NewBinding(
+		key.WithKeys("Y"),
+		key.WithHelp("Y", "copy URI"),
+	)
+	copyIDKey = key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "copy ID"),
+	)
+)
+
+// actionMsg reports the outcome of an action on a result, shown as a status
+// message in the list it was started from.
+type actionMsg struct {
+	status string
+	err    error
+}
+
+// openTarget opens target, a URL or spotify: URI, with the configured opener
+// command or the system's default handler.
+func openTarget(opener, target string) error {
+	args := strings.Fields(opener)
+	if len(args) == 0 {
+		return openBrowser(target)
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], target)...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// copyToClipboard uses the system clipboard when there is one. Over SSH, or
+// without a clipboard tool, it asks the terminal to copy instead with an
+// OSC 52 escape sequence.
+var copyToClipboard = func(text string) error {
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(os.Stderr)
+	return err
+}
+
+// itemAction runs the open or copy action bound to msg on the selected item,
+// reporting whether msg was one of them.
+func (m *model) itemAction(msg tea.KeyMsg) (tea.Cmd, bool) {
+	item, ok := m.selectedItem()
+	if !ok {
+		return nil, false
+	}
+
+	opener := m.client.Config.Opener
+	open := func(target string) tea.Cmd {
+		return func() tea.Msg {
+			if err := openTarget(opener, target); err != nil {
+				return actionMsg{err: fmt.Errorf("could not open %s: %w", target, err)}
+			}
+			return actionMsg{status: "Opened " + target}
+		}
+	}
+	copyText := func(what, text string) tea.Cmd {
+		return func() tea.Msg {
+			if err := copyToClipboard(text); err != nil {
+				return actionMsg{err: fmt.Errorf("could not copy %s: %w", what, err)}
+			}
+			return actionMsg{status: fmt.Sprintf("Copied %s %s", what, text)}
+		}
+	}
+
+	switch {
+	case key.Matches(msg, playKey):
+		return m.playItem(item), true
+	case key.Matches(msg, queueKey):
+		return m.queueItem(item), true
+	case key.Matches(msg, saveKey):
+		return m.toggleSaved(item), true
+	case key.Matches(msg, addToPlaylistKey):
+		return m.showPlaylists(item), true
+	case key.Matches(msg, bookmarkKey):
+		return m.toggleBookmark(item), true
+	case key.Matches(msg, openWebKey):
+		return open(item.url), true
+	case key.Matches(msg, openAppKey):
+		return open(item.uri), true
+	case key.Matches(msg, copyURLKey):
+		return copyText("URL", item.url), true
+	case key.Matches(msg, copyURIKey):
+		return copyText("URI", item.uri), true
+	case key.Matches(msg, copyIDKey):
+		return copyText("ID", item.id), true
+	}
+	return nil, false
+}
+
+func (m *model) playItem(item resultItem) tea.Cmd {
+	var contextURI string
+	if m.view == DetailView && len(m.details) > 0 {
+		contextURI = m.details[len(m.details)-1].item.uri
+	}
+
+	client, device := m.client, m.client.Config.Player.Device
+	opts := playOptions(item.uri, contextURI)
+	return func() tea.Msg {
+		if err := client.play(context.Background(), device, opts); err != nil {
+			return actionMsg{err: errors.New(describeError(err))}
+		}
+		return actionMsg{status: "Playing " + item.name}
+	}
+}
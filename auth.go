@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const defaultRedirectURI = "http://127.0.0.1:8888/callback"
+
+var defaultScopes = []string{
+	"user-read-private",
+	"user-read-playback-state",
+	"user-modify-playback-state",
+	"user-read-currently-playing",
+	"user-library-read",
+	"user-library-modify",
+	"playlist-read-private",
+	"playlist-read-collaborative",
+	"playlist-modify-private",
+	"playlist-modify-public",
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+var openBrowser = func(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+type authResult struct {
+	code string
+	err  error
+}
+
+// login runs the Authorization Code with PKCE flow: it serves the redirect
+// URI on a loopback listener, sends the user to Spotify's consent page and
+// exchanges the returned code for a token that includes a refresh token.
+func (c *Client) login(ctx context.Context) error {
+	redirectURI := c.Config.API.RedirectURI
+	if redirectURI == "" {
+		redirectURI = defaultRedirectURI
+	}
+	redirect, err := url.Parse(redirectURI)
+	if err != nil {
+		return fmt.Errorf("invalid redirectUri: %w", err)
+	}
+
+	scopes := c.Config.API.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+
+	verifier, err := randomString(64)
+	if err != nil {
+		return err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %w", redirect.Host, err)
+	}
+
+	// A redirect URI without a path is served at the root, but only there,
+	// so the browser asking for a favicon doesn't count as the callback.
+	pattern := redirect.Path
+	if pattern == "" || pattern == "/" {
+		pattern = "/{$}"
+	}
+
+	results := make(chan authResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var result authResult
+		switch {
+		case q.Get("state") != state:
+			result.err = errors.New("login failed: state mismatch")
+		case q.Get("error") != "":
+			result.err = fmt.Errorf("login failed: %s", q.Get("error"))
+		default:
+			result.code = q.Get("code")
+		}
+
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Logged in to spotify-cli, you can close this window.")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	authURL := c.AccountsURL + "/authorize?" + url.Values{
+		"client_id":             []string{c.Config.API.ClientID},
+		"response_type":         []string{"code"},
+		"redirect_uri":          []string{redirectURI},
+		"code_challenge_method": []string{"S256"},
+		"code_challenge":        []string{codeChallenge(verifier)},
+		"state":                 []string{state},
+		"scope":                 []string{strings.Join(scopes, " ")},
+	}.Encode()
+
+	fmt.Fprintf(os.Stderr, "Opening the Spotify login page. If it doesn't open, visit:\n\n%s\n\n", authURL)
+	_ = openBrowser(authURL)
+
+	var result authResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if result.err != nil {
+		return result.err
+	}
+
+	token, err := c.requestToken(ctx, url.Values{
+		"grant_type":    []string{"authorization_code"},
+		"code":          []string{result.code},
+		"redirect_uri":  []string{redirectURI},
+		"client_id":     []string{c.Config.API.ClientID},
+		"code_verifier": []string{verifier},
+	}, "")
+	if err != nil {
+		return err
+	}
+	return c.storeToken(token)
+}
+
+func (c *Client) logout() error {
+	err := os.Remove(c.Config.TokenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	for _, path := range []string{"/callback", "/", ""} {
+		t.Run("path "+path, func(t *testing.T) {
+			testLogin(t, path)
+		})
+	}
+}
+
+func testLogin(t *testing.T, path string) {
+	client, server := newTestClient(t)
+
+	// Pick a free port for the redirect URI.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Config.API.RedirectURI = "http://" + listener.Addr().String() + path
+	listener.Close()
+
+	// Approve the login the way the user would in their browser.
+	defer func(open func(string) error) { openBrowser = open }(openBrowser)
+	openBrowser = func(target string) error {
+		go func() {
+			resp, err := http.Get(target)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+
+	if client.loggedIn() {
+		t.Fatal("logged in before logging in")
+	}
+	if err := client.login(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !client.loggedIn() {
+		t.Fatal("not logged in after logging in")
+	}
+	if _, err := client.getCurrentUser(context.Background()); err != nil {
+		t.Errorf("got %v getting the user", err)
+	}
+	if calls := server.APICalls(); calls != 1 {
+		t.Errorf("got %d API calls, want 1", calls)
+	}
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	bookmarkKey = key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "bookmark"),
+	)
+	showBookmarksKey = key.NewBinding(
+		key.WithKeys("f4"),
+		key.WithHelp("f4", "bookmarks"),
+	)
+)
+
+// bookmark is an item kept in the local bookmarks, which work without
+// logging in. Bookmarks are stored by URI, with enough of the item to list
+// and act on it without looking it up again.
+type bookmark struct {
+	Category string    `json:"category"`
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Detail   string    `json:"detail"`
+	URL      string    `json:"url"`
+	Tags     []string  `json:"tags,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	AddedAt  time.Time `json:"addedAt"`
+}
+
+// loadBookmarks reads the bookmarks at path, keyed by URI. Bookmarks that
+// don't exist yet are empty.
+func loadBookmarks(path string) (map[string]bookmark, error) {
+	bookmarks := map[string]bookmark{}
+	if path == "" {
+		return bookmarks, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return bookmarks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &bookmarks); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bookmarks, nil
+}
+
+// changeBookmarks applies change to the bookmarks at path and saves them,
+// replacing the file in one go so it's never left half written.
+func changeBookmarks(path string, change func(map[string]bookmark)) (map[string]bookmark, error) {
+	bookmarks, err := loadBookmarks(path)
+	if err != nil {
+		return nil, err
+	}
+	change(bookmarks)
+	if path == "" {
+		return bookmarks, nil
+	}
+
+	data, err := json.MarshalIndent(bookmarks, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, writeFileAtomic(path, append(data, '\n'))
+}
+
+// parseTags splits a comma separated list of tags, dropping empty and
+// repeated ones.
+func parseTags(s string) []string {
+	var tags []string
+	for tag := range strings.SplitSeq(s, ",") {
+		tag = strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(tag), "#")), "-")
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func formatTags(tags []string) string {
+	var s []string
+	for _, tag := range tags {
+		s = append(s, "#"+tag)
+	}
+	return strings.Join(s, " ")
+}
+
+type bookmarkItem struct {
+	resultItem
+	tags []string
+	note string
+}
+
+func (i bookmarkItem) Description() string {
+	parts := []string{categoryStyle.Render(i.category)}
+	if i.detail != "" {
+		parts = append(parts, i.detail)
+	}
+	if len(i.tags) > 0 {
+		parts = append(parts, formatTags(i.tags))
+	}
+	if i.note != "" {
+		parts = append(parts, i.note)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// FilterValue lets the filter find bookmarks by their tags and notes too.
+func (i bookmarkItem) FilterValue() string {
+	return strings.Join([]string{i.name, i.category, formatTags(i.tags), i.note}, " ")
+}
+
+// toggleBookmark bookmarks item, or removes its bookmark if it has one.
+func (m *model) toggleBookmark(item resultItem) tea.Cmd {
+	if item.uri == "" {
+		return m.activeList().NewStatusMessage("Only Spotify items can be bookmarked")
+	}
+
+	var status string
+	bookmarks, err := changeBookmarks(m.client.Config.BookmarksPath, func(bookmarks map[string]bookmark) {
+		if _, ok := bookmarks[item.uri]; ok {
+			delete(bookmarks, item.uri)
+			status = "Removed the bookmark for " + item.name
+			return
+		}
+		bookmarks[item.uri] = bookmark{
+			Category: item.category,
+			ID:       item.id,
+			Name:     item.name,
+			Detail:   item.detail,
+			URL:      item.url,
+			AddedAt:  time.Now(),
+		}
+		status = "Bookmarked " + item.name
+	})
+	if err != nil {
+		return m.activeList().NewStatusMessage(fmt.Sprintf("Could not save the bookmarks: %v", err))
+	}
+
+	cmd := m.activeList().NewStatusMessage(status)
+	if m.view == BookmarksView {
+		cmd = tea.Batch(cmd, m.setBookmarkItems(bookmarks))
+	}
+	return cmd
+}
+
+// setBookmarkItems lists bookmarks, the newest first.
+func (m *model) setBookmarkItems(bookmarks map[string]bookmark) tea.Cmd {
+	var items []list.Item
+	for uri, b := range bookmarks {
+		items = append(items, bookmarkItem{
+			resultItem: resultItem{category: b.Category, id: b.ID, name: b.Name, detail: b.Detail, url: b.URL, uri: uri},
+			tags:       b.Tags,
+			note:       b.Note,
+		})
+	}
+	slices.SortFunc(items, func(a, b list.Item) int {
+		return cmp.Or(
+			bookmarks[b.(bookmarkItem).uri].AddedAt.Compare(bookmarks[a.(bookmarkItem).uri].AddedAt),
+			cmp.Compare(a.(bookmarkItem).uri, b.(bookmarkItem).uri),
+		)
+	})
+
+	cmd := m.bookmarkList.SetItems(items)
+	// Keep the cursor on an item when the last one was removed.
+	if last := len(m.bookmarkList.VisibleItems()) - 1; m.bookmarkList.Index() > last {
+		m.bookmarkList.Select(max(last, 0))
+	}
+	return cmd
+}
+
+func (m *model) showBookmarks() tea.Cmd {
+	bookmarks, err := loadBookmarks(m.client.Config.BookmarksPath)
+	if err != nil {
+		return m.activeList().NewStatusMessage(fmt.Sprintf("Could not read the bookmarks: %v", err))
+	}
+
+	if m.view != BookmarksView {
+		m.bookmarkList.ResetFilter()
+		m.bookmarkList.Select(0)
+		m.pushView(BookmarksView)
+	}
+	return m.setBookmarkItems(bookmarks)
+}
+
+// editBookmark starts editing the tags or note of the selected bookmark.
+func (m *model) editBookmark(field string) tea.Cmd {
+	item, ok := m.bookmarkList.SelectedItem().(bookmarkItem)
+	if !ok {
+		return nil
+	}
+
+	m.editingBookmark = field
+	m.bookmarkInput.Reset()
+	m.bookmarkInput.Placeholder = "Note"
+	value := item.note
+	if field == "tags" {
+		m.bookmarkInput.Placeholder = "Comma separated tags"
+		value = strings.Join(item.tags, ", ")
+	}
+	m.bookmarkInput.SetValue(value)
+	m.bookmarkInput.CursorEnd()
+	return m.bookmarkInput.Focus()
+}
+
+// saveBookmarkEdit stores the tags or note being edited.
+func (m *model) saveBookmarkEdit() tea.Cmd {
+	field, value := m.editingBookmark, m.bookmarkInput.Value()
+	m.editingBookmark = ""
+	m.bookmarkInput.Blur()
+
+	item, ok := m.bookmarkList.SelectedItem().(bookmarkItem)
+	if !ok {
+		return nil
+	}
+	bookmarks, err := changeBookmarks(m.client.Config.BookmarksPath, func(bookmarks map[string]bookmark) {
+		b, ok := bookmarks[item.uri]
+		if !ok {
+			return
+		}
+		if field == "tags" {
+			b.Tags = parseTags(value)
+		} else {
+			b.Note = strings.TrimSpace(value)
+		}
+		bookmarks[item.uri] = b
+	})
+	if err != nil {
+		return m.bookmarkList.NewStatusMessage(fmt.Sprintf("Could not save the bookmarks: %v", err))
+	}
+	return m.setBookmarkItems(bookmarks)
+}
+
+func (m model) updateBookmarks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.editingBookmark != "" {
+		switch msg.String() {
+		case "esc":
+			m.editingBookmark = ""
+			m.bookmarkInput.Blur()
+			return m, nil
+		case "enter":
+			return m, m.saveBookmarkEdit()
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+		var cmd tea.Cmd
+		m.bookmarkInput, cmd = m.bookmarkInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.bookmarkList.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, bookmarksKeys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, bookmarksKeys.Back):
+			if m.bookmarkList.FilterState() == list.FilterApplied && msg.String() == "esc" {
+				m.bookmarkList.ResetFilter()
+				return m, nil
+			}
+			m.popView()
+			return m, nil
+		case key.Matches(msg, bookmarksKeys.Open):
+			return m, m.openDetail()
+		case key.Matches(msg, bookmarksKeys.Tags):
+			return m, m.editBookmark("tags")
+		case key.Matches(msg, bookmarksKeys.Note):
+			return m, m.editBookmark("note")
+		case key.Matches(msg, bookmarksKeys.Remove):
+			if item, ok := m.selectedItem(); ok {
+				return m, m.toggleBookmark(item)
+			}
+			return m, nil
+		}
+		if cmd, ok := m.itemAction(msg); ok {
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.bookmarkList, cmd = m.bookmarkList.Update(msg)
+	return m, cmd
+}
+
+func (m model) bookmarksView() string {
+	var s strings.Builder
+
+	switch m.editingBookmark {
+	case "tags":
+		s.WriteString("\n  Tags: ")
+		s.WriteString(m.bookmarkInput.View())
+	case "note":
+		s.WriteString("\n  Note: ")
+		s.WriteString(m.bookmarkInput.View())
+	default:
+		s.WriteString("\n  ")
+		s.WriteString(m.tagSummary())
+	}
+	s.WriteString("\n")
+	s.WriteString(m.bookmarkList.View())
+
+	s.WriteString("\n\n")
+	s.WriteString(m.help.View(bookmarksKeys))
+
+	return s.String()
+}
+
+// tagSummary lists the tags in use with how many bookmarks have them, the
+// most used first.
+func (m model) tagSummary() string {
+	counts := map[string]int{}
+	for _, item := range m.bookmarkList.Items() {
+		for _, tag := range item.(bookmarkItem).tags {
+			counts[tag]++
+		}
+	}
+	if len(counts) == 0 {
+		return "No tags yet"
+	}
+
+	tags := slices.Collect(maps.Keys(counts))
+	slices.SortFunc(tags, func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+	var parts []string
+	for _, tag := range tags {
+		parts = append(parts, fmt.Sprintf("#%s (%d)", tag, counts[tag]))
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render("Tags: " + strings.Join(parts, " "))
+}
+
+func newBookmarkInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.CharLimit = 500
+	ti.Width = 60
+	return ti
+}
+
+type bookmarksKeyMap struct {
+	Open          key.Binding
+	Tags          key.Binding
+	Note          key.Binding
+	Remove        key.Binding
+	Play          key.Binding
+	Queue         key.Binding
+	Save          key.Binding
+	AddToPlaylist key.Binding
+	OpenWeb       key.Binding
+	OpenApp       key.Binding
+	CopyURL       key.Binding
+	CopyURI       key.Binding
+	CopyID        key.Binding
+	Back          key.Binding
+	Quit          key.Binding
+}
+
+func (k bookmarksKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Quit}
+}
+
+func (k bookmarksKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Open, k.Tags, k.Note, k.Remove},
+		{k.Play, k.Queue, k.Save, k.AddToPlaylist, k.OpenWeb, k.OpenApp, k.CopyURL, k.CopyURI, k.CopyID},
+		{k.Back, k.Quit},
+	}
+}
+
+var bookmarksKeys = bookmarksKeyMap{
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "view details"),
+	),
+	Tags: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "edit tags"),
+	),
+	Note: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "edit note"),
+	),
+	Remove: key.NewBinding(
+		key.WithKeys("x", "delete"),
+		key.WithHelp("x", "remove bookmark"),
+	),
+	Play:          playKey,
+	Queue:         queueKey,
+	Save:          saveKey,
+	AddToPlaylist: addToPlaylistKey,
+	OpenWeb:       openWebKey,
+	OpenApp:       openAppKey,
+	CopyURL:       copyURLKey,
+	CopyURI:       copyURIKey,
+	CopyID:        copyIDKey,
+	Back: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc/q", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+type ExternalUrls struct {
+	Spotify string `json:"spotify"`
+}
+
+type Page[T any] struct {
+	Href     string `json:"href"`
+	Limit    int    `json:"limit"`
+	Next     string `json:"next"`
+	Offset   int    `json:"offset"`
+	Previous string `json:"previous"`
+	Total    int    `json:"total"`
+	Items    []T    `json:"items"`
+}
+
+type SimplifiedArtist struct {
+	ExternalUrls ExternalUrls `json:"external_urls"`
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	URI          string       `json:"uri"`
+}
+
+type Artist struct {
+	SimplifiedArtist
+	Followers struct {
+		Total int `json:"total"`
+	} `json:"followers"`
+	Genres     []string `json:"genres"`
+	Popularity int      `json:"popularity"`
+}
+
+type SimplifiedAlbum struct {
+	AlbumType    string             `json:"album_type"`
+	TotalTracks  int                `json:"total_tracks"`
+	ExternalUrls ExternalUrls       `json:"external_urls"`
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	ReleaseDate  string             `json:"release_date"`
+	Type         string             `json:"type"`
+	URI          string             `json:"uri"`
+	Artists      []SimplifiedArtist `json:"artists"`
+}
+
+type Album struct {
+	SimplifiedAlbum
+	Tracks     Page[SimplifiedTrack] `json:"tracks"`
+	Label      string                `json:"label"`
+	Popularity int                   `json:"popularity"`
+	Copyrights []struct {
+		Text string `json:"text"`
+		Type string `json:"type"`
+	} `json:"copyrights"`
+}
+
+type SimplifiedTrack struct {
+	Artists      []SimplifiedArtist `json:"artists"`
+	DiscNumber   int                `json:"disc_number"`
+	DurationMs   int                `json:"duration_ms"`
+	Explicit     bool               `json:"explicit"`
+	ExternalUrls ExternalUrls       `json:"external_urls"`
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	TrackNumber  int                `json:"track_number"`
+	Type         string             `json:"type"`
+	URI          string             `json:"uri"`
+}
+
+type Track struct {
+	SimplifiedTrack
+	Album       SimplifiedAlbum `json:"album"`
+	ExternalIds struct {
+		Isrc string `json:"isrc"`
+		Ean  string `json:"ean"`
+		Upc  string `json:"upc"`
+	} `json:"external_ids"`
+	Popularity int `json:"popularity"`
+}
+
+// PlaylistTrack is an entry of a playlist. Track is nil for items that are
+// no longer available, and holds an episode's fields when Type is "episode".
+type PlaylistTrack struct {
+	AddedAt string `json:"added_at"`
+	Track   *Track `json:"track"`
+}
+
+type SimplifiedEpisode struct {
+	Description  string       `json:"description"`
+	DurationMs   int          `json:"duration_ms"`
+	ExternalUrls ExternalUrls `json:"external_urls"`
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	ReleaseDate  string       `json:"release_date"`
+	Type         string       `json:"type"`
+	URI          string       `json:"uri"`
+}
+
+type SimplifiedChapter struct {
+	ChapterNumber int          `json:"chapter_number"`
+	Description   string       `json:"description"`
+	DurationMs    int          `json:"duration_ms"`
+	ExternalUrls  ExternalUrls `json:"external_urls"`
+	ID            string       `json:"id"`
+	Name          string       `json:"name"`
+	Type          string       `json:"type"`
+	URI           string       `json:"uri"`
+}
+
+func marketQuery(market string) url.Values {
+	q := url.Values{}
+	if market != "" {
+		q.Add("market", market)
+	}
+	return q
+}
+
+func pageQuery(market string, offset, limit int) url.Values {
+	q := marketQuery(market)
+	if offset != 0 {
+		q.Add("offset", strconv.Itoa(offset))
+	}
+	if limit != 0 {
+		q.Add("limit", strconv.Itoa(limit))
+	}
+	return q
+}
+
+func (c *Client) getAlbum(ctx context.Context, id, market string) (*Album, error) {
+	var album Album
+	if err := c.get(ctx, "/albums/"+url.PathEscape(id), marketQuery(market), &album); err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+func (c *Client) getArtist(ctx context.Context, id string) (*Artist, error) {
+	var artist Artist
+	if err := c.get(ctx, "/artists/"+url.PathEscape(id), nil, &artist); err != nil {
+		return nil, err
+	}
+	return &artist, nil
+}
+
+func (c *Client) getArtistTopTracks(ctx context.Context, id, market string) ([]Track, error) {
+	var resp struct {
+		Tracks []Track `json:"tracks"`
+	}
+	if err := c.get(ctx, "/artists/"+url.PathEscape(id)+"/top-tracks", marketQuery(market), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Tracks, nil
+}
+
+func (c *Client) getArtistAlbums(ctx context.Context, id, market string, offset, limit int) (*Page[SimplifiedAlbum], error) {
+	q := pageQuery(market, offset, limit)
+	q.Add("include_groups", "album,single")
+
+	var page Page[SimplifiedAlbum]
+	if err := c.get(ctx, "/artists/"+url.PathEscape(id)+"/albums", q, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) getTrack(ctx context.Context, id, market string) (*Track, error) {
+	var track Track
+	if err := c.get(ctx, "/tracks/"+url.PathEscape(id), marketQuery(market), &track); err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+func (c *Client) getPlaylist(ctx context.Context, id, market string) (*SimplifiedPlaylist, error) {
+	var playlist SimplifiedPlaylist
+	if err := c.get(ctx, "/playlists/"+url.PathEscape(id), marketQuery(market), &playlist); err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+func (c *Client) getPlaylistTracks(ctx context.Context, id, market string, offset, limit int) (*Page[PlaylistTrack], error) {
+	q := pageQuery(market, offset, limit)
+	q.Add("additional_types", "track,episode")
+
+	var page Page[PlaylistTrack]
+	if err := c.get(ctx, "/playlists/"+url.PathEscape(id)+"/tracks", q, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) getShowEpisodes(ctx context.Context, id, market string, offset, limit int) (*Page[SimplifiedEpisode], error) {
+	var page Page[SimplifiedEpisode]
+	if err := c.get(ctx, "/shows/"+url.PathEscape(id)+"/episodes", pageQuery(market, offset, limit), &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) getAudiobookChapters(ctx context.Context, id, market string, offset, limit int) (*Page[SimplifiedChapter], error) {
+	var page Page[SimplifiedChapter]
+	if err := c.get(ctx, "/audiobooks/"+url.PathEscape(id)+"/chapters", pageQuery(market, offset, limit), &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) getMarkets(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Markets []string `json:"markets"`
+	}
+	if err := c.get(ctx, "/markets", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Markets, nil
+}
@@ -0,0 +1,857 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+var searchTypes = []string{"album", "artist", "playlist", "track", "show", "episode", "audiobook"}
+
+var outputFormats = []string{"json", "ndjson", "csv", "tsv", "table"}
+
+// parseInterspersed parses flags that may appear before, after or between
+// positional arguments, which the flag package doesn't allow on its own.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+type searchRow struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	By   string `json:"by"`
+	URI  string `json:"uri"`
+	URL  string `json:"url"`
+}
+
+func searchRows(r *SearchResults) []searchRow {
+	var rows []searchRow
+
+	for _, a := range r.Albums.Items {
+		artistNames := []string{}
+		for _, ar := range a.Artists {
+			artistNames = append(artistNames, ar.Name)
+		}
+		rows = append(rows, searchRow{"album", a.ID, a.Name, strings.Join(artistNames, ", "), a.URI, a.ExternalUrls.Spotify})
+	}
+	for _, a := range r.Artists.Items {
+		rows = append(rows, searchRow{"artist", a.ID, a.Name, "", a.URI, a.ExternalUrls.Spotify})
+	}
+	for _, p := range r.Playlists.Items {
+		rows = append(rows, searchRow{"playlist", p.ID, p.Name, p.Owner.DisplayName, p.URI, p.ExternalUrls.Spotify})
+	}
+	for _, t := range r.Tracks.Items {
+		artistNames := []string{}
+		for _, a := range t.Artists {
+			artistNames = append(artistNames, a.Name)
+		}
+		rows = append(rows, searchRow{"track", t.ID, t.Name, strings.Join(artistNames, ", "), t.URI, t.ExternalUrls.Spotify})
+	}
+	for _, s := range r.Shows.Items {
+		rows = append(rows, searchRow{"show", s.ID, s.Name, s.Publisher, s.URI, s.ExternalUrls.Spotify})
+	}
+	for _, e := range r.Episodes.Items {
+		rows = append(rows, searchRow{"episode", e.ID, e.Name, "", e.URI, e.ExternalUrls.Spotify})
+	}
+	for _, a := range r.Audiobooks.Items {
+		authorNames := []string{}
+		for _, au := range a.Authors {
+			authorNames = append(authorNames, au.Name)
+		}
+		rows = append(rows, searchRow{"audiobook", a.ID, a.Name, strings.Join(authorNames, ", "), a.URI, a.ExternalUrls.Spotify})
+	}
+
+	return rows
+}
+
+func writeSearchRows(w io.Writer, format string, rows []searchRow) error {
+	header := []string{"type", "id", "name", "by", "uri", "url"}
+	record := func(r searchRow) []string {
+		return []string{r.Type, r.ID, r.Name, r.By, r.URI, r.URL}
+	}
+
+	switch format {
+	case "json":
+		if rows == nil {
+			rows = []searchRow{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, r := range rows {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if format == "tsv" {
+			cw.Comma = '\t'
+		}
+		cw.Write(header)
+		for _, r := range rows {
+			cw.Write(record(r))
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "TYPE\tNAME\tBY\tID")
+		for _, r := range rows {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Type, r.Name, r.By, r.ID)
+		}
+		return tw.Flush()
+	}
+}
+
+const usage = `Usage: spotify-cli [--fake] [command]
+
+Without a command, spotify-cli starts the interactive search. With --fake
+(or SPOTIFY_CLI_FAKE=1) it talks to a built-in fake of the Spotify API
+instead, so no config.json or network access is needed.
+
+Commands:
+  search <query>    search the catalog and print the results
+  player [action]   show or control playback, see spotify-cli player -h
+  playlist <action> list, create and change playlists, see spotify-cli playlist -h
+  export playlist <playlist>
+                    back up a playlist as M3U8, XSPF, CSV or JSON
+  import <file>     create a playlist from the tracks of a CSV or M3U file
+  login             log in with your Spotify account
+  logout            forget the stored login
+`
+
+func runCommand(client *Client, name string, args []string) int {
+	switch name {
+	case "search":
+		return runSearch(client, args)
+	case "player":
+		return runPlayer(client, args)
+	case "playlist":
+		return runPlaylist(client, args)
+	case "export":
+		return runExport(client, args)
+	case "import":
+		return runImport(client, args)
+	case "login":
+		return runLogin(client)
+	case "logout":
+		return runLogout(client)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		return exitUsage
+	}
+}
+
+func runLogin(client *Client) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := client.login(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	fmt.Println("Logged in.")
+	return exitOK
+}
+
+func runLogout(client *Client) int {
+	if err := client.logout(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	fmt.Println("Logged out.")
+	return exitOK
+}
+
+func runSearch(client *Client, args []string) int {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: spotify-cli search <query> [flags]")
+		fs.PrintDefaults()
+	}
+	types := fs.String("type", "track", "comma-separated categories: "+strings.Join(searchTypes, ","))
+	defaults := client.Config.Search
+	// Spotify returns 20 results per category unless told otherwise.
+	limit := fs.Int("limit", cmp.Or(defaults.Limit, 20), "maximum results per category (1-50)")
+	offset := fs.Int("offset", 0, "index of the first result to return")
+	market := fs.String("market", defaults.Market, "ISO 3166-1 alpha-2 country code")
+	includeExternal := fs.Bool("include-external", defaults.IncludeExternal, "include externally hosted audio in episode results")
+	format := fs.String("format", "table", "output format: "+strings.Join(outputFormats, "|"))
+
+	positional, err := parseInterspersed(fs, args)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	usageError := func(msg string) int {
+		fmt.Fprintln(os.Stderr, "spotify-cli search:", msg)
+		fs.Usage()
+		return exitUsage
+	}
+
+	query := strings.Join(positional, " ")
+	if query == "" {
+		return usageError("missing search query")
+	}
+	for _, t := range strings.Split(*types, ",") {
+		if !slices.Contains(searchTypes, t) {
+			return usageError(fmt.Sprintf("unknown type %q", t))
+		}
+	}
+	if err := validateQuery(query, *types); err != nil {
+		return usageError(err.Error())
+	}
+	if *limit < 1 || *limit > 50 {
+		return usageError("limit must be between 1 and 50")
+	}
+	if *offset < 0 {
+		return usageError("offset must not be negative")
+	}
+	if !slices.Contains(outputFormats, *format) {
+		return usageError(fmt.Sprintf("unknown format %q", *format))
+	}
+
+	client.OnRetry = func(e RetryEvent) {
+		fmt.Fprintf(os.Stderr, "spotify-cli: %s\n", strings.ToLower(describeRetry(e)))
+	}
+
+	searchQuery := SearchQuery{
+		Q:      query,
+		Type:   *types,
+		Market: *market,
+		Limit:  *limit,
+		Offset: *offset,
+	}
+	if *includeExternal {
+		searchQuery.IncludeExternal = "audio"
+	}
+
+	results, err := client.search(context.Background(), searchQuery)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, describeError(err))
+		return exitError
+	}
+
+	if err := writeSearchRows(os.Stdout, *format, searchRows(results)); err != nil {
+		fmt.Fprintln(os.Stderr, describeError(err))
+		return exitError
+	}
+
+	return exitOK
+}
+
+const playerUsage = `Usage: spotify-cli player [action] [flags]
+
+Actions:
+  status                   show what's playing (default)
+  play [uri]               resume, or play a track, episode, album, playlist, ...
+  pause                    pause playback
+  next                     skip to the next track
+  previous                 skip to the previous track
+  seek <seconds>           seek to a position in the current track
+  volume <percent>         set the volume, 0-100
+  shuffle on|off           toggle shuffle
+  repeat track|context|off set the repeat mode
+  devices                  list the available devices
+  transfer <device-id>     move playback to another device
+  queue [uri]              show the queue, or add a track or episode to it
+
+Flags:
+`
+
+func runPlayer(client *Client, args []string) int {
+	fs := flag.NewFlagSet("player", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), playerUsage)
+		fs.PrintDefaults()
+	}
+	deviceID := fs.String("device", "", "ID of the device to control instead of the default or active one")
+
+	positional, err := parseInterspersed(fs, args)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	if *deviceID == "" {
+		*deviceID = client.Config.Player.Device
+	}
+
+	usageError := func(msg string) int {
+		fmt.Fprintln(os.Stderr, "spotify-cli player:", msg)
+		fs.Usage()
+		return exitUsage
+	}
+
+	action := "status"
+	if len(positional) > 0 {
+		action, positional = positional[0], positional[1:]
+	}
+	arg := ""
+	if len(positional) > 0 {
+		arg = positional[0]
+	}
+	needsArg := map[string]bool{"seek": true, "volume": true, "shuffle": true, "repeat": true, "transfer": true}
+	if needsArg[action] && arg == "" {
+		return usageError(action + " needs an argument")
+	}
+
+	ctx := context.Background()
+	switch action {
+	case "status":
+		var state *PlaybackState
+		if state, err = client.getPlaybackState(ctx); err != nil {
+			break
+		}
+		fmt.Println(describePlayback(state))
+		return exitOK
+	case "play":
+		var opts *PlayOptions
+		if arg != "" {
+			if uriKind(arg) == "" {
+				return usageError(fmt.Sprintf("%q is not a spotify: URI", arg))
+			}
+			opts = playOptions(arg, "")
+		}
+		err = client.play(ctx, *deviceID, opts)
+	case "pause":
+		err = client.pause(ctx, *deviceID)
+	case "next":
+		err = client.skipNext(ctx, *deviceID)
+	case "previous":
+		err = client.skipPrevious(ctx, *deviceID)
+	case "seek":
+		seconds, convErr := strconv.ParseFloat(arg, 64)
+		if convErr != nil || seconds < 0 {
+			return usageError("seek needs a position in seconds")
+		}
+		err = client.seek(ctx, *deviceID, time.Duration(seconds*float64(time.Second)))
+	case "volume":
+		percent, convErr := strconv.Atoi(strings.TrimSuffix(arg, "%"))
+		if convErr != nil || percent < 0 || percent > 100 {
+			return usageError("volume must be between 0 and 100")
+		}
+		err = client.setVolume(ctx, *deviceID, percent)
+	case "shuffle":
+		if arg != "on" && arg != "off" {
+			return usageError("shuffle must be on or off")
+		}
+		err = client.setShuffle(ctx, *deviceID, arg == "on")
+	case "repeat":
+		if arg != "track" && arg != "context" && arg != "off" {
+			return usageError("repeat must be track, context or off")
+		}
+		err = client.setRepeat(ctx, *deviceID, arg)
+	case "devices":
+		var devices []Device
+		if devices, err = client.getDevices(ctx); err != nil {
+			break
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, d := range devices {
+			fmt.Fprintln(tw, describeDevice(d, client.Config.Player.Device))
+		}
+		tw.Flush()
+		return exitOK
+	case "transfer":
+		err = client.transferPlayback(ctx, arg, false)
+	case "queue":
+		if arg != "" {
+			if kind := uriKind(arg); kind != "track" && kind != "episode" {
+				return usageError(fmt.Sprintf("%q is not a track or episode URI", arg))
+			}
+			err = client.addToQueue(ctx, *deviceID, arg)
+			break
+		}
+		var queue *Queue
+		if queue, err = client.getQueue(ctx); err != nil {
+			break
+		}
+		for _, item := range queue.Queue {
+			fmt.Println(describeQueueItem(item))
+		}
+		return exitOK
+	default:
+		return usageError(fmt.Sprintf("unknown action %q", action))
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, describeError(err))
+		return exitError
+	}
+	return exitOK
+}
+
+// describeDevice formats a device as tab separated columns, marking the
+// active and default devices.
+func describeDevice(d Device, defaultID string) string {
+	var marks []string
+	if d.IsActive {
+		marks = append(marks, "active")
+	}
+	if d.ID == defaultID {
+		marks = append(marks, "default")
+	}
+	volume := "-"
+	if d.VolumePercent != nil {
+		volume = fmt.Sprintf("%d%%", *d.VolumePercent)
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s", d.Name, d.Type, volume, strings.Join(marks, ", "), d.ID)
+}
+
+func describeQueueItem(item PlaybackItem) string {
+	by := artistNames(item.Artists)
+	if item.Show != nil {
+		by = item.Show.Name
+	}
+	return fmt.Sprintf("%s · %s (%s)", item.Name, by, formatDuration(item.DurationMs))
+}
+
+// describePlayback summarises the playback state in one line.
+func describePlayback(state *PlaybackState) string {
+	if state == nil {
+		return "Nothing is playing"
+	}
+	if state.Item == nil {
+		return fmt.Sprintf("Nothing is playing on %s", state.Device.Name)
+	}
+
+	status := "Playing"
+	if !state.IsPlaying {
+		status = "Paused"
+	}
+	by := artistNames(state.Item.Artists)
+	if state.Item.Show != nil {
+		by = state.Item.Show.Name
+	}
+	return fmt.Sprintf("%s %s · %s (%s / %s) on %s",
+		status, state.Item.Name, by,
+		formatDuration(state.ProgressMs), formatDuration(state.Item.DurationMs),
+		state.Device.Name)
+}
+
+const playlistUsage = `Usage: spotify-cli playlist <action> [flags]
+
+Playlists can be given as an ID, a spotify: URI or an open.spotify.com URL.
+
+Actions:
+  list                        list your playlists
+  create <name>               create a playlist and print its ID
+  details <playlist>          change the details given by --name, --description,
+                              --public and --collaborative
+  add <playlist> <uri>...     add tracks or episodes at --position, or the end
+  remove <playlist> <uri>...  remove every occurrence of tracks or episodes
+  move <playlist> <from> <to> move --length items starting at index <from> to
+                              before index <to>
+
+add, remove and move print the playlist's new snapshot ID, which --snapshot
+takes to make sure remove and move apply to the playlist as it was then.
+
+Flags:
+`
+
+func runPlaylist(client *Client, args []string) int {
+	fs := flag.NewFlagSet("playlist", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), playlistUsage)
+		fs.PrintDefaults()
+	}
+	name := fs.String("name", "", "new name of the playlist")
+	description := fs.String("description", "", "description of the playlist")
+	public := fs.Bool("public", false, "whether the playlist shows on your profile")
+	collaborative := fs.Bool("collaborative", false, "whether others can change the playlist")
+	position := fs.Int("position", -1, "index to add items at, the end if negative")
+	length := fs.Int("length", 1, "number of items to move")
+	snapshot := fs.String("snapshot", "", "snapshot ID of the playlist to remove or move items from")
+
+	positional, err := parseInterspersed(fs, args)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	usageError := func(msg string) int {
+		fmt.Fprintln(os.Stderr, "spotify-cli playlist:", msg)
+		fs.Usage()
+		return exitUsage
+	}
+
+	if len(positional) == 0 {
+		return usageError("missing action")
+	}
+	action, positional := positional[0], positional[1:]
+	minArgs, ok := map[string]int{"list": 0, "create": 1, "details": 1, "add": 2, "remove": 2, "move": 3}[action]
+	if !ok {
+		return usageError(fmt.Sprintf("unknown action %q", action))
+	}
+	if len(positional) < minArgs {
+		return usageError(action + " is missing arguments")
+	}
+
+	// Details that aren't given stay as they are, so only flags that are
+	// set are passed on.
+	var details PlaylistDetails
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			details.Name = *name
+		case "description":
+			details.Description = description
+		case "public":
+			details.Public = public
+		case "collaborative":
+			details.Collaborative = collaborative
+		}
+	})
+
+	var id string
+	if action != "list" && action != "create" {
+		var ok bool
+		if id, ok = parseSpotifyID("playlist", positional[0]); !ok {
+			return usageError(fmt.Sprintf("%q is not a playlist", positional[0]))
+		}
+	}
+	uris := positional[min(1, len(positional)):]
+	if action == "add" || action == "remove" {
+		for _, uri := range uris {
+			if kind := uriKind(uri); kind != "track" && kind != "episode" {
+				return usageError(fmt.Sprintf("%q is not a track or episode URI", uri))
+			}
+		}
+	}
+
+	ctx := context.Background()
+	var newSnapshot string
+	switch action {
+	case "list":
+		var user *User
+		if user, err = client.getCurrentUser(ctx); err != nil {
+			break
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for offset := 0; ; {
+			var page *Page[SimplifiedPlaylist]
+			if page, err = client.getMyPlaylists(ctx, offset, 50); err != nil {
+				break
+			}
+			for _, p := range page.Items {
+				fmt.Fprintln(tw, describePlaylist(p, user.ID))
+			}
+			offset += len(page.Items)
+			if page.Next == "" || len(page.Items) == 0 {
+				break
+			}
+		}
+		tw.Flush()
+	case "create":
+		details.Name = strings.Join(positional, " ")
+		// Spotify makes playlists public unless told otherwise.
+		details.Public = public
+		var playlist *SimplifiedPlaylist
+		if playlist, err = client.createPlaylist(ctx, details); err != nil {
+			break
+		}
+		fmt.Println(playlist.ID)
+		return exitOK
+	case "details":
+		if details == (PlaylistDetails{}) {
+			return usageError("details needs at least one of --name, --description, --public or --collaborative")
+		}
+		err = client.changePlaylistDetails(ctx, id, details)
+	case "add":
+		newSnapshot, err = client.addToPlaylist(ctx, id, uris, *position)
+	case "remove":
+		newSnapshot, err = client.removeFromPlaylist(ctx, id, *snapshot, uris)
+	case "move":
+		from, fromErr := strconv.Atoi(positional[1])
+		to, toErr := strconv.Atoi(positional[2])
+		if fromErr != nil || toErr != nil || from < 0 || to < 0 || *length < 1 {
+			return usageError("move needs two indexes and a --length of at least 1")
+		}
+		newSnapshot, err = client.reorderPlaylist(ctx, id, *snapshot, from, *length, to)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, describeError(err))
+		return exitError
+	}
+	if newSnapshot != "" {
+		fmt.Println(newSnapshot)
+	}
+	return exitOK
+}
+
+// describePlaylist formats a playlist as tab separated columns, with the owner
+// left out of the user's own playlists.
+func describePlaylist(p SimplifiedPlaylist, userID string) string {
+	owner := p.Owner.DisplayName
+	if p.Owner.ID == userID {
+		owner = ""
+	}
+	var marks []string
+	if p.Public {
+		marks = append(marks, "public")
+	}
+	if p.Collaborative {
+		marks = append(marks, "collaborative")
+	}
+	return fmt.Sprintf("%s\t%d items\t%s\t%s\t%s", p.Name, p.Tracks.Total, owner, strings.Join(marks, ", "), p.ID)
+}
+
+const exportUsage = `Usage: spotify-cli export playlist <playlist> [flags]
+
+Writes every item of a playlist, given as an ID, a spotify: URI or an
+open.spotify.com URL, to stdout or --output. The format is taken from
+--format, the extension of --output or the "export" section of config.json,
+in that order.
+
+Flags:
+`
+
+func runExport(client *Client, args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), exportUsage)
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "", "export format: "+strings.Join(exportFormats, ", "))
+	output := fs.String("output", "", "file to write to instead of stdout")
+	market := fs.String("market", client.Config.Search.Market, "ISO 3166-1 alpha-2 country code")
+
+	positional, err := parseInterspersed(fs, args)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	usageError := func(msg string) int {
+		fmt.Fprintln(os.Stderr, "spotify-cli export:", msg)
+		fs.Usage()
+		return exitUsage
+	}
+
+	if len(positional) != 2 || positional[0] != "playlist" {
+		return usageError("expected playlist and a playlist to export")
+	}
+	id, ok := parseSpotifyID("playlist", positional[1])
+	if !ok {
+		return usageError(fmt.Sprintf("%q is not a playlist", positional[1]))
+	}
+	if *format == "" {
+		*format = exportFormat(*output, client.Config.Export.Format)
+	}
+	if !slices.Contains(exportFormats, *format) {
+		return usageError(fmt.Sprintf("unknown format %q", *format))
+	}
+
+	export, err := fetchPlaylistExport(context.Background(), client, id, *market)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, describeError(err))
+		return exitError
+	}
+
+	if *output == "" {
+		err = export.write(os.Stdout, *format)
+	} else {
+		err = writeExportFile(*output, export, *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
+
+const importUsage = `Usage: spotify-cli import <file> [flags]
+
+Creates a playlist from the tracks listed in a CSV or M3U file, or stdin if
+<file> is -. CSV files need a header row with title, isrc or uri columns,
+and artist and duration columns help tell tracks apart. M3U files name tracks
+"Artist - Title" on their #EXTINF lines.
+
+Each track is searched for by ISRC, then artist and title, unless a uri or
+candidate_uri column gives it. Tracks that can't be found, or that match more
+than one track about as well, are left out and listed in a CSV report, one
+row each, with their closest candidate in candidate_uri and any others after
+it. The report can be imported again: change or clear candidate_uri where
+the candidate is wrong first.
+
+Flags:
+`
+
+func runImport(client *Client, args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), importUsage)
+		fs.PrintDefaults()
+	}
+	name := fs.String("name", "", "name of the playlist, by default the file's name")
+	public := fs.Bool("public", false, "whether the playlist shows on your profile")
+	format := fs.String("format", "", "import format: "+strings.Join(importFormats, ", ")+", by default from the extension")
+	report := fs.String("report", "", "file to write the report to instead of stderr")
+	dryRun := fs.Bool("dry-run", false, "only match the tracks, without creating the playlist")
+	market := fs.String("market", client.Config.Search.Market, "ISO 3166-1 alpha-2 country code")
+
+	positional, err := parseInterspersed(fs, args)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	usageError := func(msg string) int {
+		fmt.Fprintln(os.Stderr, "spotify-cli import:", msg)
+		fs.Usage()
+		return exitUsage
+	}
+
+	if len(positional) != 1 {
+		return usageError("expected a file to import")
+	}
+	path := positional[0]
+	if *format == "" {
+		*format = importFormat(path)
+	}
+	if !slices.Contains(importFormats, *format) {
+		return usageError(fmt.Sprintf("unknown format %q", *format))
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		defer f.Close()
+		in = f
+	}
+	list, err := readImport(in, *format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not read %s: %v\n", path, err)
+		return exitError
+	}
+	if *name == "" {
+		*name = list.name
+	}
+	if *name == "" && path != "-" {
+		*name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if *name == "" {
+		*name = "Imported"
+	}
+
+	ctx := context.Background()
+	results, err := resolveImport(ctx, client, list, *market)
+	if err != nil {
+		var lineErr *importLineError
+		if errors.As(err, &lineErr) {
+			fmt.Fprintf(os.Stderr, "line %d: %s\n", lineErr.line, describeError(lineErr.err))
+		} else {
+			fmt.Fprintln(os.Stderr, describeError(err))
+		}
+		return exitError
+	}
+	uris := matchedURIs(results)
+	if err := writeImportResults(*report, results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	summary := fmt.Sprintf("%d of %d tracks matched", len(uris), len(results))
+	if left := len(results) - len(uris); left > 0 {
+		where := "stderr"
+		if *report != "" {
+			where = *report
+		}
+		summary += fmt.Sprintf(", see %s for the other %d", where, left)
+	}
+	if *dryRun || len(uris) == 0 {
+		fmt.Println(summary)
+		if len(uris) == 0 && !*dryRun {
+			fmt.Fprintln(os.Stderr, "No playlist was created.")
+			return exitError
+		}
+		return exitOK
+	}
+
+	playlist, err := client.createPlaylist(ctx, PlaylistDetails{Name: *name, Public: public})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, describeError(err))
+		return exitError
+	}
+	if _, err := client.addToPlaylist(ctx, playlist.ID, uris, -1); err != nil {
+		fmt.Fprintf(os.Stderr, "created %s (%s), but could not add its tracks: %s\n", playlist.Name, playlist.ID, describeError(err))
+		return exitError
+	}
+	fmt.Printf("Created %s (%s), %s\n", playlist.Name, playlist.ID, summary)
+	return exitOK
+}
+
+// writeImportResults writes the report of an import to path, or stderr if
+// path is empty and there's anything to report.
+func writeImportResults(path string, results []importResult) error {
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := writeImportReport(f, results); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	if len(matchedURIs(results)) == len(results) {
+		return nil
+	}
+	return writeImportReport(os.Stderr, results)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSearchRows(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	results, err := client.search(context.Background(), SearchQuery{Q: "nevermind", Type: "album,track", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := searchRows(results)
+
+	for _, format := range outputFormats {
+		t.Run(format, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := writeSearchRows(&b, format, rows); err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, "search_rows_"+format, b.String())
+		})
+	}
+
+	var b bytes.Buffer
+	if err := writeSearchRows(&b, "json", nil); err != nil || b.String() != "[]\n" {
+		t.Errorf("got %q and %v, want an empty JSON array", b.String(), err)
+	}
+}
+
+// discardOutput sends what a command prints to /dev/null for the rest of
+// the test.
+func discardOutput(t *testing.T) {
+	t.Helper()
+
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = null, null
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		null.Close()
+	})
+}
+
+func TestRunSearch(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		fail int
+		want int
+	}{
+		{"ok", []string{"nirvana"}, 0, exitOK},
+		{"help", []string{"-h"}, 0, exitOK},
+		{"missing query", nil, 0, exitUsage},
+		{"unknown type", []string{"nirvana", "--type", "song"}, 0, exitUsage},
+		{"zero limit", []string{"nirvana", "--limit", "0"}, 0, exitUsage},
+		{"limit over 50", []string{"nirvana", "--limit", "51"}, 0, exitUsage},
+		{"negative offset", []string{"nirvana", "--offset", "-1"}, 0, exitUsage},
+		{"unknown format", []string{"nirvana", "--format", "xml"}, 0, exitUsage},
+		{"api error", []string{"nirvana"}, http.StatusBadRequest, exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			if tt.fail != 0 {
+				server.Fail(tt.fail, "Bad request")
+			}
+			discardOutput(t)
+
+			if got := runSearch(client, tt.args); got != tt.want {
+				t.Errorf("got exit code %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunPlaylist(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"missing action", nil, exitUsage},
+		{"unknown action", []string{"foo"}, exitUsage},
+		{"missing arguments", []string{"add", "spotify:playlist:1"}, exitUsage},
+		{"not a playlist", []string{"add", "spotify:album:1", "spotify:track:1"}, exitUsage},
+		{"list", []string{"list"}, exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newLoggedInClient(t)
+			discardOutput(t)
+
+			if got := runPlaylist(client, tt.args); got != tt.want {
+				t.Errorf("got exit code %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunPlaylistCreate(t *testing.T) {
+	client, _ := newLoggedInClient(t)
+	discardOutput(t)
+
+	if got := runPlaylist(client, []string{"create", "Mixtape"}); got != exitOK {
+		t.Fatalf("got exit code %d, want %d", got, exitOK)
+	}
+	playlists, err := fetchEditablePlaylists(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(playlists) == 0 || playlists[0].Name != "Mixtape" {
+		t.Fatalf("got playlists %v, want Mixtape first", playlists)
+	}
+	if playlists[0].Public {
+		t.Error("created a public playlist without --public")
+	}
+}
+
+func TestRunImportError(t *testing.T) {
+	client, server := newLoggedInClient(t)
+	discardOutput(t)
+	path := filepath.Join(t.TempDir(), "mixtape.csv")
+	if err := os.WriteFile(path, []byte("artist,title\nNirvana,Lithium\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+	os.Stderr = stderr
+
+	server.Fail(http.StatusBadRequest, "Bad request")
+	if got := runImport(client, []string{path}); got != exitError {
+		t.Fatalf("got exit code %d, want %d", got, exitError)
+	}
+	got, err := os.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "line 2: Spotify returned an error (400): Bad request\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
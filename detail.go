@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type detailPage struct {
+	item   resultItem
+	header []string
+	list   list.Model
+}
+
+type detailContent struct {
+	header []string
+	items  []list.Item
+}
+
+type detailMsg struct {
+	seq     int
+	item    resultItem
+	content *detailContent
+	err     error
+}
+
+func formatDuration(ms int) string {
+	secs := ms / 1000
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func artistNames(artists []SimplifiedArtist) string {
+	names := []string{}
+	for _, a := range artists {
+		names = append(names, a.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func hasDetail(item resultItem) bool {
+	switch item.category {
+	case "Album", "Artist", "Track", "Playlist", "Show", "Audiobook":
+		return item.id != ""
+	}
+	return false
+}
+
+func trackItem(t SimplifiedTrack, extra string) resultItem {
+	detail := fmt.Sprintf("%s · by %s", formatDuration(t.DurationMs), artistNames(t.Artists))
+	if extra != "" {
+		detail += " · " + extra
+	}
+	return resultItem{
+		category: "Track",
+		id:       t.ID,
+		name:     t.Name,
+		detail:   detail,
+		url:      t.ExternalUrls.Spotify,
+		uri:      t.URI,
+	}
+}
+
+func albumItem(a SimplifiedAlbum) resultItem {
+	return resultItem{
+		category: "Album",
+		id:       a.ID,
+		name:     a.Name,
+		detail:   fmt.Sprintf("by %s · Released: %s", artistNames(a.Artists), a.ReleaseDate),
+		url:      a.ExternalUrls.Spotify,
+		uri:      a.URI,
+	}
+}
+
+func loadDetail(ctx context.Context, client *Client, item resultItem, market string) (*detailContent, error) {
+	var content detailContent
+
+	switch item.category {
+	case "Album":
+		album, err := client.getAlbum(ctx, item.id, market)
+		if err != nil {
+			return nil, err
+		}
+		total := 0
+		for _, t := range album.Tracks.Items {
+			total += t.DurationMs
+			content.items = append(content.items, trackItem(t, fmt.Sprintf("#%d", t.TrackNumber)))
+		}
+		content.header = []string{
+			fmt.Sprintf("%s by %s", capitalize(album.AlbumType), artistNames(album.Artists)),
+			fmt.Sprintf("Released: %s · %d tracks · %s", album.ReleaseDate, album.TotalTracks, formatDuration(total)),
+		}
+		if album.Label != "" {
+			content.header = append(content.header, "Label: "+album.Label)
+		}
+	case "Artist":
+		artist, err := client.getArtist(ctx, item.id)
+		if err != nil {
+			return nil, err
+		}
+		topTracks, err := client.getArtistTopTracks(ctx, item.id, market)
+		if err != nil {
+			return nil, err
+		}
+		albums, err := client.getArtistAlbums(ctx, item.id, market, 0, 20)
+		if err != nil {
+			return nil, err
+		}
+		content.header = []string{
+			fmt.Sprintf("Followers: %d · Popularity: %d", artist.Followers.Total, artist.Popularity),
+			fmt.Sprintf("Genres: %s", strings.Join(artist.Genres, ", ")),
+		}
+		for _, t := range topTracks {
+			content.items = append(content.items, trackItem(t.SimplifiedTrack, "Top track"))
+		}
+		for _, a := range albums.Items {
+			content.items = append(content.items, albumItem(a))
+		}
+	case "Track":
+		track, err := client.getTrack(ctx, item.id, market)
+		if err != nil {
+			return nil, err
+		}
+		content.header = []string{
+			fmt.Sprintf("by %s · Album: %s", artistNames(track.Artists), track.Album.Name),
+			fmt.Sprintf("Duration: %s · Track %d of %d · Popularity: %d",
+				formatDuration(track.DurationMs), track.TrackNumber, track.Album.TotalTracks, track.Popularity),
+		}
+		if track.Explicit {
+			content.header = append(content.header, "Explicit")
+		}
+		if track.ExternalIds.Isrc != "" {
+			content.header = append(content.header, "ISRC: "+track.ExternalIds.Isrc)
+		}
+		content.items = append(content.items, albumItem(track.Album))
+		for _, a := range track.Artists {
+			content.items = append(content.items, resultItem{
+				category: "Artist",
+				id:       a.ID,
+				name:     a.Name,
+				detail:   "Artist",
+				url:      a.ExternalUrls.Spotify,
+				uri:      a.URI,
+			})
+		}
+	case "Playlist":
+		page, err := client.getPlaylistTracks(ctx, item.id, market, 0, 100)
+		if err != nil {
+			return nil, err
+		}
+		total := 0
+		for _, pt := range page.Items {
+			if pt.Track == nil {
+				continue
+			}
+			total += pt.Track.DurationMs
+			if pt.Track.Type == "episode" {
+				content.items = append(content.items, resultItem{
+					category: "Episode",
+					id:       pt.Track.ID,
+					name:     pt.Track.Name,
+					detail:   formatDuration(pt.Track.DurationMs),
+					url:      pt.Track.ExternalUrls.Spotify,
+					uri:      pt.Track.URI,
+				})
+				continue
+			}
+			content.items = append(content.items, trackItem(pt.Track.SimplifiedTrack, "Album: "+pt.Track.Album.Name))
+		}
+		content.header = []string{
+			item.detail,
+			fmt.Sprintf("Showing %d of %d · %s", len(content.items), page.Total, formatDuration(total)),
+		}
+	case "Show":
+		page, err := client.getShowEpisodes(ctx, item.id, market, 0, 50)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page.Items {
+			content.items = append(content.items, resultItem{
+				category: "Episode",
+				id:       e.ID,
+				name:     e.Name,
+				detail:   fmt.Sprintf("%s · Released: %s", formatDuration(e.DurationMs), e.ReleaseDate),
+				url:      e.ExternalUrls.Spotify,
+				uri:      e.URI,
+			})
+		}
+		content.header = []string{
+			item.detail,
+			fmt.Sprintf("Showing %d of %d episodes", len(content.items), page.Total),
+		}
+	case "Audiobook":
+		page, err := client.getAudiobookChapters(ctx, item.id, market, 0, 50)
+		if err != nil {
+			return nil, err
+		}
+		total := 0
+		for _, c := range page.Items {
+			total += c.DurationMs
+			content.items = append(content.items, resultItem{
+				category: "Chapter",
+				id:       c.ID,
+				name:     c.Name,
+				detail:   fmt.Sprintf("Chapter %d · %s", c.ChapterNumber+1, formatDuration(c.DurationMs)),
+				url:      c.ExternalUrls.Spotify,
+				uri:      c.URI,
+			})
+		}
+		content.header = []string{
+			item.detail,
+			fmt.Sprintf("Showing %d of %d chapters · %s", len(content.items), page.Total, formatDuration(total)),
+		}
+	}
+
+	return &content, nil
+}
+
+func (m *model) fetchDetail(item resultItem) tea.Cmd {
+	seq, client, market := m.startFetch(), m.client, m.client.Config.Search.Market
+	return func() tea.Msg {
+		content, err := loadDetail(context.Background(), client, item, market)
+		return detailMsg{seq: seq, item: item, content: content, err: err}
+	}
+}
+
+// activeList returns the list the user is currently looking at, so status
+// messages and spinners end up where they can be seen.
+func (m *model) activeList() *list.Model {
+	switch {
+	case m.view == DetailView && len(m.details) > 0:
+		return &m.details[len(m.details)-1].list
+	case m.view == QueueView:
+		return &m.queueList
+	case m.view == DevicesView:
+		return &m.deviceList
+	case m.view == LibraryView:
+		return &m.libraryList
+	case m.view == PlaylistsView:
+		return &m.playlistList
+	case m.view == HistoryView:
+		return &m.historyList
+	case m.view == BookmarksView:
+		return &m.bookmarkList
+	}
+	return &m.resultList
+}
+
+// selectedItem is the item selected in the active list, for lists of
+// results and of bookmarks.
+func (m *model) selectedItem() (resultItem, bool) {
+	switch item := m.activeList().SelectedItem().(type) {
+	case resultItem:
+		return item, true
+	case bookmarkItem:
+		return item.resultItem, true
+	}
+	return resultItem{}, false
+}
+
+func (m *model) openDetail() tea.Cmd {
+	l := m.activeList()
+	item, ok := m.selectedItem()
+	if !ok {
+		return nil
+	}
+	if !hasDetail(item) {
+		return l.NewStatusMessage(fmt.Sprintf("No details available for %s items", strings.ToLower(item.category)))
+	}
+
+	return tea.Batch(l.StartSpinner(), m.fetchDetail(item))
+}
+
+func (m *model) pushDetail(msg detailMsg) tea.Cmd {
+	if msg.seq != m.fetchSeq {
+		return nil
+	}
+	l := m.activeList()
+	l.StopSpinner()
+	if msg.err != nil {
+		return l.NewStatusMessage(describeError(msg.err))
+	}
+
+	dl := list.New(msg.content.items, list.NewDefaultDelegate(), m.width, m.detailListHeight(len(msg.content.header)))
+	dl.Title = msg.item.category + " · " + msg.item.name
+	dl.DisableQuitKeybindings()
+
+	m.pushView(DetailView)
+	m.details = append(m.details, detailPage{item: msg.item, header: msg.content.header, list: dl})
+	return m.checkSaved(msg.content.items)
+}
+
+func (m model) detailListHeight(headerLines int) int {
+	return max(m.height-m.footerHeight()-headerLines-1, 4)
+}
+
+func (m model) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if len(m.details) == 0 {
+		m.popView()
+		return m, nil
+	}
+	page := &m.details[len(m.details)-1]
+
+	if page.list.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, detailKeys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, detailKeys.Back):
+			if page.list.FilterState() == list.FilterApplied && msg.String() == "esc" {
+				page.list.ResetFilter()
+				return m, nil
+			}
+			m.cancelFetch()
+			m.details = m.details[:len(m.details)-1]
+			if len(m.details) == 0 {
+				m.popView()
+			}
+			return m, nil
+		case key.Matches(msg, detailKeys.Open):
+			return m, m.openDetail()
+		case key.Matches(msg, detailKeys.ShowQueue):
+			return m, m.showQueue()
+		case key.Matches(msg, detailKeys.ShowDevices):
+			return m, m.showDevices()
+		case key.Matches(msg, detailKeys.ShowLibrary):
+			return m, m.showLibrary()
+		}
+		if cmd, ok := m.itemAction(msg); ok {
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	page.list, cmd = page.list.Update(msg)
+	return m, cmd
+}
+
+func (m model) detailView() string {
+	if len(m.details) == 0 {
+		return ""
+	}
+	var s strings.Builder
+	page := m.details[len(m.details)-1]
+
+	s.WriteString("\n")
+	for _, line := range page.header {
+		s.WriteString("  ")
+		s.WriteString(line)
+		s.WriteString("\n")
+	}
+	s.WriteString(page.list.View())
+
+	s.WriteString("\n\n")
+	s.WriteString(m.help.View(detailKeys))
+
+	return s.String()
+}
+
+type detailKeyMap struct {
+	Open          key.Binding
+	ShowQueue     key.Binding
+	ShowDevices   key.Binding
+	ShowLibrary   key.Binding
+	Play          key.Binding
+	Queue         key.Binding
+	Save          key.Binding
+	AddToPlaylist key.Binding
+	Bookmark      key.Binding
+	OpenWeb       key.Binding
+	OpenApp       key.Binding
+	CopyURL       key.Binding
+	CopyURI       key.Binding
+	CopyID        key.Binding
+	Back          key.Binding
+	Quit          key.Binding
+}
+
+func (k detailKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Quit}
+}
+
+func (k detailKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Open, k.ShowQueue, k.ShowDevices, k.ShowLibrary},
+		{k.Play, k.Queue, k.Save, k.AddToPlaylist, k.Bookmark, k.OpenWeb, k.OpenApp, k.CopyURL, k.CopyURI, k.CopyID},
+		{k.Back, k.Quit},
+	}
+}
+
+var detailKeys = detailKeyMap{
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "view details"),
+	),
+	ShowQueue:     showQueueKey,
+	ShowDevices:   showDevicesKey,
+	ShowLibrary:   showLibraryKey,
+	Play:          playKey,
+	Queue:         queueKey,
+	Save:          saveKey,
+	AddToPlaylist: addToPlaylistKey,
+	Bookmark:      bookmarkKey,
+	OpenWeb:       openWebKey,
+	OpenApp:       openAppKey,
+	CopyURL:       copyURLKey,
+	CopyURI:       copyURIKey,
+	CopyID:        copyIDKey,
+	Back: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc/q", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
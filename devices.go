@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var showDevicesKey = key.NewBinding(
+	key.WithKeys("D"),
+	key.WithHelp("D", "devices"),
+)
+
+type deviceItem struct {
+	device    Device
+	isDefault bool
+}
+
+func (i deviceItem) Title() string { return i.device.Name }
+func (i deviceItem) Description() string {
+	parts := []string{categoryStyle.Render(i.device.Type)}
+	if i.device.VolumePercent != nil {
+		parts = append(parts, fmt.Sprintf("Volume %d%%", *i.device.VolumePercent))
+	}
+	if i.device.IsActive {
+		parts = append(parts, "Playing here")
+	}
+	if i.isDefault {
+		parts = append(parts, "Default")
+	}
+	return strings.Join(parts, " · ")
+}
+func (i deviceItem) FilterValue() string { return i.device.Name }
+
+type devicesMsg struct {
+	seq     int
+	devices []Device
+	err     error
+}
+
+func (m *model) fetchDevices() tea.Cmd {
+	seq, client := m.startFetch(), m.client
+	return func() tea.Msg {
+		devices, err := client.getDevices(context.Background())
+		return devicesMsg{seq: seq, devices: devices, err: err}
+	}
+}
+
+func (m *model) showDevices() tea.Cmd {
+	return tea.Batch(m.activeList().StartSpinner(), m.fetchDevices())
+}
+
+func (m *model) updateDeviceList(msg devicesMsg) tea.Cmd {
+	if msg.seq != m.fetchSeq {
+		return nil
+	}
+	l := m.activeList()
+	l.StopSpinner()
+	if msg.err != nil {
+		return l.NewStatusMessage(describeError(msg.err))
+	}
+
+	if m.view != DevicesView {
+		m.deviceList.ResetFilter()
+		m.deviceList.Select(0)
+		m.pushView(DevicesView)
+	}
+	return m.setDeviceItems(msg.devices)
+}
+
+func (m *model) setDeviceItems(devices []Device) tea.Cmd {
+	items := make([]list.Item, len(devices))
+	for i, d := range devices {
+		items[i] = deviceItem{device: d, isDefault: d.ID == m.client.Config.Player.Device}
+	}
+	return m.deviceList.SetItems(items)
+}
+
+// targetDevice describes where play commands go.
+func (m model) targetDevice() string {
+	if player := m.client.Config.Player; player.Device != "" {
+		return player.DeviceName
+	}
+	return "the active device"
+}
+
+// toggleDefault makes the selected device the one play commands go to, or
+// goes back to the active device when it already is.
+func (m *model) toggleDefault(item deviceItem) tea.Cmd {
+	player := &m.client.Config.Player
+	if player.Device == item.device.ID {
+		player.Device, player.DeviceName = "", ""
+	} else {
+		player.Device, player.DeviceName = item.device.ID, item.device.Name
+	}
+
+	var devices []Device
+	for _, listItem := range m.deviceList.Items() {
+		devices = append(devices, listItem.(deviceItem).device)
+	}
+	cmd := m.setDeviceItems(devices)
+
+	status := "Playing on " + m.targetDevice()
+	if err := m.client.Config.savePlayer(); err != nil {
+		status = fmt.Sprintf("Could not save the default device: %v", err)
+	}
+	return tea.Batch(cmd, m.deviceList.NewStatusMessage(status))
+}
+
+func (m *model) transferTo(item deviceItem) tea.Cmd {
+	client := m.client
+	return func() tea.Msg {
+		if err := client.transferPlayback(context.Background(), item.device.ID, false); err != nil {
+			return actionMsg{err: errors.New(describeError(err))}
+		}
+		return actionMsg{status: "Moved playback to " + item.device.Name}
+	}
+}
+
+func (m model) updateDevices(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.deviceList.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, devicesKeys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, devicesKeys.Back):
+			if m.deviceList.FilterState() == list.FilterApplied && msg.String() == "esc" {
+				m.deviceList.ResetFilter()
+				return m, nil
+			}
+			m.popView()
+			return m, nil
+		case key.Matches(msg, devicesKeys.Refresh):
+			return m, m.showDevices()
+		}
+
+		if item, ok := m.deviceList.SelectedItem().(deviceItem); ok {
+			switch {
+			case key.Matches(msg, devicesKeys.Transfer):
+				return m, m.transferTo(item)
+			case key.Matches(msg, devicesKeys.Default):
+				return m, m.toggleDefault(item)
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.deviceList, cmd = m.deviceList.Update(msg)
+	return m, cmd
+}
+
+func (m model) devicesView() string {
+	var s strings.Builder
+
+	s.WriteString("\n  Play commands go to ")
+	s.WriteString(m.targetDevice())
+	s.WriteString("\n")
+	s.WriteString(m.deviceList.View())
+
+	s.WriteString("\n\n")
+	s.WriteString(m.help.View(devicesKeys))
+
+	return s.String()
+}
+
+type devicesKeyMap struct {
+	Transfer key.Binding
+	Default  key.Binding
+	Refresh  key.Binding
+	Back     key.Binding
+	Quit     key.Binding
+}
+
+func (k devicesKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Quit}
+}
+
+func (k devicesKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Transfer, k.Default, k.Refresh},
+		{k.Back, k.Quit},
+	}
+}
+
+var devicesKeys = devicesKeyMap{
+	Transfer: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "move playback here"),
+	),
+	Default: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "play here by default"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc/q", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var exportFormats = []string{"m3u8", "xspf", "csv", "json"}
+
+const defaultExportFormat = "m3u8"
+
+var exportKey = key.NewBinding(
+	key.WithKeys("e"),
+	key.WithHelp("e", "export playlist"),
+)
+
+// playlistExport is a playlist with every one of its items, as written to
+// an export.
+type playlistExport struct {
+	playlist SimplifiedPlaylist
+	items    []PlaylistTrack
+}
+
+// exportRow is one item of a playlist in the CSV and JSON exports.
+type exportRow struct {
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Artists    string `json:"artists"`
+	Album      string `json:"album"`
+	DurationMs int    `json:"duration_ms"`
+	ISRC       string `json:"isrc"`
+	AddedAt    string `json:"added_at"`
+	URI        string `json:"uri"`
+	URL        string `json:"url"`
+}
+
+// fetchPlaylistExport fetches playlist id and all pages of its items.
+// Items that are no longer available are left out.
+func fetchPlaylistExport(ctx context.Context, client *Client, id, market string) (*playlistExport, error) {
+	playlist, err := client.getPlaylist(ctx, id, market)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &playlistExport{playlist: *playlist}
+	for offset := 0; ; {
+		page, err := client.getPlaylistTracks(ctx, id, market, offset, 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if item.Track != nil {
+				export.items = append(export.items, item)
+			}
+		}
+		offset += len(page.Items)
+		if page.Next == "" || len(page.Items) == 0 {
+			return export, nil
+		}
+	}
+}
+
+func (e *playlistExport) rows() []exportRow {
+	rows := []exportRow{}
+	for _, item := range e.items {
+		t := item.Track
+		rows = append(rows, exportRow{
+			Type:       t.Type,
+			Name:       t.Name,
+			Artists:    artistNames(t.Artists),
+			Album:      t.Album.Name,
+			DurationMs: t.DurationMs,
+			ISRC:       t.ExternalIds.Isrc,
+			AddedAt:    item.AddedAt,
+			URI:        t.URI,
+			URL:        t.ExternalUrls.Spotify,
+		})
+	}
+	return rows
+}
+
+// exportFormat picks the format for an export written to path from its
+// extension, falling back to fallback.
+func exportFormat(path, fallback string) string {
+	switch ext := strings.TrimPrefix(filepath.Ext(path), "."); ext {
+	case "m3u", "m3u8":
+		return "m3u8"
+	case "xspf", "csv", "json":
+		return ext
+	}
+	if fallback == "" {
+		return defaultExportFormat
+	}
+	return fallback
+}
+
+// exportFileName turns the name of a playlist into a file name that's safe
+// on any system.
+func exportFileName(name, format string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || strings.Trim(name, ".") == "" {
+		name = "playlist"
+	}
+	return name + "." + format
+}
+
+// oneLine keeps names from breaking up line based formats.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func (e *playlistExport) write(w io.Writer, format string) error {
+	switch format {
+	case "m3u8":
+		return e.writeM3U8(w)
+	case "xspf":
+		return e.writeXSPF(w)
+	case "csv":
+		return e.writeCSV(w)
+	case "json":
+		return e.writeJSON(w)
+	}
+	return fmt.Errorf("unknown export format %q, use one of %s", format, strings.Join(exportFormats, ", "))
+}
+
+// writeM3U8 writes an extended M3U playlist pointing at the items' Spotify
+// links, with their durations in whole seconds.
+func (e *playlistExport) writeM3U8(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#PLAYLIST:%s\n", oneLine(e.playlist.Name))
+	for _, row := range e.rows() {
+		title := row.Name
+		if row.Artists != "" {
+			title = row.Artists + " - " + row.Name
+		}
+		location := row.URL
+		if location == "" {
+			location = row.URI
+		}
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n%s\n", (row.DurationMs+500)/1000, oneLine(title), location)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type xspfPlaylist struct {
+	XMLName    xml.Name    `xml:"playlist"`
+	Version    string      `xml:"version,attr"`
+	Xmlns      string      `xml:"xmlns,attr"`
+	Title      string      `xml:"title"`
+	Creator    string      `xml:"creator,omitempty"`
+	Annotation string      `xml:"annotation,omitempty"`
+	Location   string      `xml:"location,omitempty"`
+	Identifier string      `xml:"identifier,omitempty"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location   string `xml:"location,omitempty"`
+	Identifier string `xml:"identifier"`
+	Title      string `xml:"title"`
+	Creator    string `xml:"creator,omitempty"`
+	Album      string `xml:"album,omitempty"`
+	Duration   int    `xml:"duration"`
+}
+
+func (e *playlistExport) writeXSPF(w io.Writer) error {
+	p := xspfPlaylist{
+		Version:    "1",
+		Xmlns:      "http://xspf.org/ns/0/",
+		Title:      e.playlist.Name,
+		Creator:    e.playlist.Owner.DisplayName,
+		Annotation: e.playlist.Description,
+		Location:   e.playlist.ExternalUrls.Spotify,
+		Identifier: e.playlist.URI,
+		Tracks:     []xspfTrack{},
+	}
+	for _, row := range e.rows() {
+		p.Tracks = append(p.Tracks, xspfTrack{
+			Location:   row.URL,
+			Identifier: row.URI,
+			Title:      row.Name,
+			Creator:    row.Artists,
+			Album:      row.Album,
+			Duration:   row.DurationMs,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (e *playlistExport) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"type", "name", "artists", "album", "duration_ms", "isrc", "added_at", "uri", "url"})
+	for _, r := range e.rows() {
+		cw.Write([]string{r.Type, r.Name, r.Artists, r.Album, strconv.Itoa(r.DurationMs), r.ISRC, r.AddedAt, r.URI, r.URL})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (e *playlistExport) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Name        string      `json:"name"`
+		Description string      `json:"description"`
+		Owner       string      `json:"owner"`
+		SnapshotID  string      `json:"snapshot_id"`
+		URI         string      `json:"uri"`
+		URL         string      `json:"url"`
+		Tracks      []exportRow `json:"tracks"`
+	}{
+		Name:        e.playlist.Name,
+		Description: e.playlist.Description,
+		Owner:       e.playlist.Owner.DisplayName,
+		SnapshotID:  e.playlist.SnapshotID,
+		URI:         e.playlist.URI,
+		URL:         e.playlist.ExternalUrls.Spotify,
+		Tracks:      e.rows(),
+	})
+}
+
+// exportPlaylist writes the playlist item is to a file named after it in
+// the export directory, in the configured format.
+func (m *model) exportPlaylist(item resultItem) tea.Cmd {
+	if item.category != "Playlist" || item.id == "" {
+		return m.activeList().NewStatusMessage("Only playlists can be exported")
+	}
+
+	client, market := m.client, m.client.Config.Search.Market
+	settings := m.client.Config.Export
+	return func() tea.Msg {
+		format := settings.Format
+		if format == "" {
+			format = defaultExportFormat
+		}
+		if !slices.Contains(exportFormats, format) {
+			return actionMsg{err: fmt.Errorf("unknown export format %q in config.json", format)}
+		}
+
+		export, err := fetchPlaylistExport(context.Background(), client, item.id, market)
+		if err != nil {
+			return actionMsg{err: errors.New(describeError(err))}
+		}
+		path, err := writeNewExportFile(settings.Dir, exportFileName(export.playlist.Name, format), export, format)
+		if err != nil {
+			return actionMsg{err: fmt.Errorf("could not export %s: %w", item.name, err)}
+		}
+		return actionMsg{status: fmt.Sprintf("Exported %d items to %s", len(export.items), path)}
+	}
+}
+
+func writeExportFile(path string, export *playlistExport, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := export.write(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeNewExportFile writes export to a new file called name in dir and
+// returns its path. Earlier exports are kept by numbering the new file, as
+// in "Mixtape (2).m3u8".
+func writeNewExportFile(dir, name string, export *playlistExport, format string) (string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for n := 1; ; n++ {
+		path := filepath.Join(dir, name)
+		if n > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", base, n, ext))
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := export.write(f, format); err != nil {
+			f.Close()
+			os.Remove(path)
+			return "", err
+		}
+		return path, f.Close()
+	}
+}
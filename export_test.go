@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExportPlaylist(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	results, err := client.search(ctx, SearchQuery{Q: "grunge", Type: "playlist"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	export, err := fetchPlaylistExport(ctx, client, results.Playlists.Items[0].ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range exportFormats {
+		t.Run(format, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := export.write(&b, format); err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, "export_"+format, b.String())
+		})
+	}
+}
+
+func TestExportPlaylistPages(t *testing.T) {
+	client, server := newLoggedInClient(t)
+	ctx := context.Background()
+
+	results, err := client.search(ctx, SearchQuery{Q: "foo fighters", Type: "track,episode"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uris := make([]string, 150)
+	for i := range uris {
+		uris[i] = results.Tracks.Items[i%len(results.Tracks.Items)].URI
+	}
+	uris = append(uris, results.Episodes.Items[0].URI)
+
+	playlist, err := client.createPlaylist(ctx, PlaylistDetails{Name: "Backup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.addToPlaylist(ctx, playlist.ID, uris, -1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The playlist and two pages of items.
+	before := server.APICalls()
+	export, err := fetchPlaylistExport(ctx, client, playlist.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := server.APICalls() - before; calls != 3 {
+		t.Errorf("got %d API calls, want 3", calls)
+	}
+
+	rows := export.rows()
+	if len(rows) != len(uris) || rows[0].ISRC == "" {
+		t.Fatalf("got %d rows starting with %+v, want %d with ISRCs", len(rows), rows[0], len(uris))
+	}
+	if last := rows[len(rows)-1]; last.Type != "episode" || last.URI != results.Episodes.Items[0].URI {
+		t.Errorf("got %+v last, want the episode", last)
+	}
+}
+
+func TestExportFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Grunge Forever", "Grunge Forever.m3u8"},
+		{"AC/DC: Best of?", "AC_DC_ Best of_.m3u8"},
+		{" .. ", "playlist.m3u8"},
+	}
+	for _, tt := range tests {
+		if got := exportFileName(tt.name, "m3u8"); got != tt.want {
+			t.Errorf("exportFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteNewExportFile(t *testing.T) {
+	dir := t.TempDir()
+	export := &playlistExport{playlist: SimplifiedPlaylist{Name: "Mixtape"}}
+
+	var paths []string
+	for range 3 {
+		path, err := writeNewExportFile(dir, "Mixtape.json", export, "json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, filepath.Base(path))
+	}
+	want := []string{"Mixtape.json", "Mixtape (2).json", "Mixtape (3).json"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("got %q, want %q", paths, want)
+	}
+}
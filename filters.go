@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// searchFilter is a field filter Spotify understands inside a search query,
+// written as name:value. Values with spaces are quoted, as in
+// artist:"Pink Floyd".
+type searchFilter struct {
+	name    string
+	example string
+	help    string
+	types   []string
+	valid   func(value string) bool
+	expects string
+}
+
+var (
+	yearPattern = regexp.MustCompile(`^(\d{4})(?:-(\d{4}))?$`)
+	isrcPattern = regexp.MustCompile(`^[A-Za-z]{2}[A-Za-z0-9]{3}\d{7}$`)
+	upcPattern  = regexp.MustCompile(`^\d{12,13}$`)
+)
+
+var searchFilters = []searchFilter{
+	{name: "artist", example: `artist:"Pink Floyd"`, help: "by artist", types: []string{"album", "artist", "track"}},
+	{name: "album", example: "album:nevermind", help: "on album", types: []string{"album", "track"}},
+	{name: "track", example: "track:creep", help: "track name", types: []string{"track"}},
+	{
+		name: "year", example: "year:1990-1999", help: "released in a year or range",
+		types: []string{"album", "artist", "track"},
+		valid: func(v string) bool {
+			m := yearPattern.FindStringSubmatch(v)
+			return m != nil && (m[2] == "" || m[1] <= m[2])
+		},
+		expects: "a year like 1991 or a range like 1990-1999",
+	},
+	{name: "genre", example: "genre:grunge", help: "artist genre", types: []string{"artist", "track"}},
+	{
+		name: "isrc", example: "isrc:USGF19942501", help: "track by ISRC", types: []string{"track"},
+		valid: isrcPattern.MatchString, expects: "a 12 character ISRC",
+	},
+	{
+		name: "upc", example: "upc:720642442524", help: "album by UPC", types: []string{"album"},
+		valid: upcPattern.MatchString, expects: "a 12 or 13 digit UPC",
+	},
+	{
+		name: "tag", example: "tag:new", help: "new releases, or tag:hipster for the least popular", types: []string{"album"},
+		valid: func(v string) bool { return v == "new" || v == "hipster" }, expects: "new or hipster",
+	},
+}
+
+func findFilter(name string) *searchFilter {
+	for i := range searchFilters {
+		if searchFilters[i].name == name {
+			return &searchFilters[i]
+		}
+	}
+	return nil
+}
+
+// queryToken is a space separated part of a search query. Quoted parts stay
+// together, and filter is set when the part is a known name:value filter.
+type queryToken struct {
+	text   string
+	filter *searchFilter
+	value  string
+}
+
+func tokenizeQuery(q string) []queryToken {
+	var parts []string
+	var cur strings.Builder
+	inQuotes := false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			cur.WriteRune(r)
+		case r == ' ' && !inQuotes:
+			if cur.Len() > 0 {
+				parts = append(parts, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		parts = append(parts, cur.String())
+	}
+
+	tokens := make([]queryToken, 0, len(parts))
+	for _, part := range parts {
+		token := queryToken{text: part}
+		if name, value, ok := strings.Cut(part, ":"); ok {
+			if f := findFilter(strings.ToLower(name)); f != nil {
+				token.filter = f
+				token.value = strings.Trim(value, `"`)
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// validateQuery checks the filters in q, and that each applies to at least
+// one of the comma separated types. Parts that aren't filters are free text
+// and always valid.
+func validateQuery(q, types string) error {
+	selected := strings.Split(types, ",")
+	for _, token := range tokenizeQuery(q) {
+		f := token.filter
+		if f == nil {
+			continue
+		}
+		if token.value == "" {
+			return fmt.Errorf("%s: needs a value, like %s", f.name, f.example)
+		}
+		if f.valid != nil && !f.valid(token.value) {
+			return fmt.Errorf("%s: expects %s", f.name, f.expects)
+		}
+		if types != "" && !slices.ContainsFunc(selected, func(t string) bool { return slices.Contains(f.types, t) }) {
+			return fmt.Errorf("%s: only applies to %s", f.name, pluralTypes(f.types))
+		}
+	}
+	return nil
+}
+
+func pluralTypes(types []string) string {
+	plural := make([]string, len(types))
+	for i, t := range types {
+		plural[i] = t + "s"
+	}
+	if len(plural) == 1 {
+		return plural[0]
+	}
+	return strings.Join(plural[:len(plural)-1], ", ") + " and " + plural[len(plural)-1]
+}
+
+// highlightQuery renders the filters in q with their names highlighted, or ""
+// when q has none.
+func highlightQuery(q string) string {
+	var parts []string
+	for _, token := range tokenizeQuery(q) {
+		if token.filter != nil {
+			parts = append(parts, categoryStyle.Render(token.filter.name+":")+token.value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func filterHelpView() string {
+	var s strings.Builder
+
+	s.WriteString(normalTitleStyle.Render("Filters:"))
+	s.WriteString("\n")
+	for _, f := range searchFilters {
+		s.WriteString(fmt.Sprintf("  %-22s %s (%s)\n", f.example, f.help, pluralTypes(f.types)))
+	}
+	s.WriteString("  Anything else is matched as free text.\n")
+
+	return s.String()
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestValidateQuery(t *testing.T) {
+	tests := []struct {
+		q, types string
+		want     string
+	}{
+		{q: "nirvana", types: "track"},
+		{q: `artist:"Pink Floyd" year:1970-1979`, types: "album"},
+		{q: "AC/DC re:stacks", types: "track"},
+		{q: "isrc:USGF19942501", types: "album,track"},
+		{q: "tag:hipster", types: "album"},
+		{q: "artist:", types: "track", want: "artist: needs a value, like artist:\"Pink Floyd\""},
+		{q: "year:199", types: "track", want: "year: expects a year like 1991 or a range like 1990-1999"},
+		{q: "year:1999-1990", types: "track", want: "year: expects a year like 1991 or a range like 1990-1999"},
+		{q: "upc:12345", types: "album", want: "upc: expects a 12 or 13 digit UPC"},
+		{q: "tag:old", types: "album", want: "tag: expects new or hipster"},
+		{q: "genre:grunge", types: "album,playlist", want: "genre: only applies to artists and tracks"},
+		{q: "tag:new", types: "track", want: "tag: only applies to albums"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			got := ""
+			if err := validateQuery(tt.q, tt.types); err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
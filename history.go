@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyLimit is how many searches the history keeps, dropping the oldest.
+const historyLimit = 200
+
+var (
+	historyKey = key.NewBinding(
+		key.WithKeys("f3"),
+		key.WithHelp("f3", "search history"),
+	)
+	recallKey = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "previous searches"),
+	)
+)
+
+// historyEntry is a search that was run, with the categories and market it
+// was run with.
+type historyEntry struct {
+	Query      string    `json:"query"`
+	Types      []string  `json:"types"`
+	Market     string    `json:"market,omitempty"`
+	SearchedAt time.Time `json:"searchedAt"`
+}
+
+func (e historyEntry) same(other historyEntry) bool {
+	return e.Query == other.Query && slices.Equal(e.Types, other.Types) && e.Market == other.Market
+}
+
+// historyPath is where the search history is kept, following the XDG base
+// directory spec for state.
+func historyPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		dir = os.Getenv("HOME") + "/.local/state"
+	}
+	return dir + "/spotify-cli/history.json"
+}
+
+// loadHistory reads the search history at path, oldest first. A history that
+// doesn't exist yet is empty.
+func loadHistory(path string) ([]historyEntry, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var history []historyEntry
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return history, nil
+}
+
+func saveHistory(path string, history []historyEntry) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// addHistory appends entry to history, dropping an earlier run of the same
+// search so that each shows up once.
+func addHistory(history []historyEntry, entry historyEntry) []historyEntry {
+	history = slices.DeleteFunc(slices.Clone(history), entry.same)
+	history = append(history, entry)
+	if len(history) > historyLimit {
+		history = history[len(history)-historyLimit:]
+	}
+	return history
+}
+
+// recordSearch adds query to the history and saves it.
+func (m *model) recordSearch(query SearchQuery) {
+	m.history = addHistory(m.history, historyEntry{
+		Query:      query.Q,
+		Types:      strings.Split(query.Type, ","),
+		Market:     query.Market,
+		SearchedAt: time.Now(),
+	})
+	m.historyIndex = len(m.history)
+	m.historyDraft = ""
+	if err := saveHistory(m.client.Config.HistoryPath, m.history); err != nil {
+		m.error = fmt.Sprintf("Could not save the search history: %v", err)
+	}
+}
+
+// recallHistory steps through the history from the search input, by -1 to
+// go back to older searches and 1 to come forward again. Coming forward past
+// the newest search gets back what was typed before.
+func (m *model) recallHistory(step int) {
+	index := m.historyIndex + step
+	if index < 0 || index > len(m.history) {
+		return
+	}
+	if m.historyIndex == len(m.history) {
+		m.historyDraft = m.textInput.Value()
+	}
+	m.historyIndex = index
+
+	if index == len(m.history) {
+		m.textInput.SetValue(m.historyDraft)
+	} else {
+		m.useEntry(m.history[index])
+	}
+	m.textInput.CursorEnd()
+}
+
+// useEntry fills in the search input and categories from entry.
+func (m *model) useEntry(entry historyEntry) {
+	m.textInput.SetValue(entry.Query)
+	for i := range m.choices {
+		m.choices[i].selected = slices.Contains(entry.Types, m.choices[i].searchType)
+	}
+}
+
+type historyItem struct {
+	entry historyEntry
+}
+
+func (i historyItem) Title() string { return i.entry.Query }
+func (i historyItem) Description() string {
+	parts := []string{categoryStyle.Render(strings.Join(i.entry.Types, ", "))}
+	if i.entry.Market != "" {
+		parts = append(parts, "Market: "+i.entry.Market)
+	}
+	parts = append(parts, i.entry.SearchedAt.Format("2 Jan 2006 15:04"))
+	return strings.Join(parts, " · ")
+}
+func (i historyItem) FilterValue() string { return i.entry.Query }
+
+func (m *model) setHistoryItems() tea.Cmd {
+	items := make([]list.Item, 0, len(m.history))
+	for _, entry := range slices.Backward(m.history) {
+		items = append(items, historyItem{entry: entry})
+	}
+	return m.historyList.SetItems(items)
+}
+
+func (m *model) showHistory() tea.Cmd {
+	m.historyList.ResetFilter()
+	m.historyList.Select(0)
+	m.pushView(HistoryView)
+	return m.setHistoryItems()
+}
+
+// rerunSearch runs the search of entry again, with the market it had then.
+func (m *model) rerunSearch(entry historyEntry) tea.Cmd {
+	m.useEntry(entry)
+	m.textInput.CursorEnd()
+	m.textInput.Focus()
+	m.focus = inputFocus
+	m.popView()
+
+	query := m.searchQuery()
+	query.Market = entry.Market
+	return m.submitSearch(query)
+}
+
+func (m *model) deleteHistory(entry historyEntry) tea.Cmd {
+	m.history = slices.DeleteFunc(slices.Clone(m.history), entry.same)
+	m.historyIndex = len(m.history)
+
+	status := "Deleted " + entry.Query
+	if err := saveHistory(m.client.Config.HistoryPath, m.history); err != nil {
+		status = fmt.Sprintf("Could not save the search history: %v", err)
+	}
+	cmd := m.setHistoryItems()
+	// Keep the cursor on an item when the last one was deleted.
+	if last := len(m.historyList.VisibleItems()) - 1; m.historyList.Index() > last {
+		m.historyList.Select(max(last, 0))
+	}
+	return tea.Batch(cmd, m.historyList.NewStatusMessage(status))
+}
+
+func (m model) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.historyList.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, historyKeys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, historyKeys.Back):
+			if m.historyList.FilterState() == list.FilterApplied && msg.String() == "esc" {
+				m.historyList.ResetFilter()
+				return m, nil
+			}
+			m.popView()
+			return m, nil
+		case key.Matches(msg, historyKeys.Run):
+			if item, ok := m.historyList.SelectedItem().(historyItem); ok {
+				return m, m.rerunSearch(item.entry)
+			}
+			return m, nil
+		case key.Matches(msg, historyKeys.Delete):
+			if item, ok := m.historyList.SelectedItem().(historyItem); ok {
+				return m, m.deleteHistory(item.entry)
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.historyList, cmd = m.historyList.Update(msg)
+	return m, cmd
+}
+
+func (m model) historyView() string {
+	var s strings.Builder
+
+	s.WriteString("\n")
+	s.WriteString(m.historyList.View())
+
+	s.WriteString("\n\n")
+	s.WriteString(m.help.View(historyKeys))
+
+	return s.String()
+}
+
+type historyKeyMap struct {
+	Run    key.Binding
+	Delete key.Binding
+	Back   key.Binding
+	Quit   key.Binding
+}
+
+func (k historyKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Quit}
+}
+
+func (k historyKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Run, k.Delete},
+		{k.Back, k.Quit},
+	}
+}
+
+var historyKeys = historyKeyMap{
+	Run: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "search again"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("x", "delete"),
+		key.WithHelp("x", "delete"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc/q", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var importFormats = []string{"csv", "m3u"}
+
+// Scores at or above matchScore are taken as the track, those at or above
+// ambiguousScore are reported for a closer look, and anything lower doesn't
+// count as a candidate at all. A match also needs to beat the best other
+// song by ambiguousMargin.
+const (
+	matchScore      = 0.85
+	ambiguousScore  = 0.6
+	ambiguousMargin = 0.05
+)
+
+// importRow is a track to find, as read from line of the input. Any of
+// artist, title, isrc and uri may be empty, and durationMs is 0 when it's not
+// known.
+type importRow struct {
+	line       int
+	artist     string
+	title      string
+	isrc       string
+	uri        string
+	durationMs int
+}
+
+func (r importRow) String() string {
+	switch {
+	case r.artist != "" && r.title != "":
+		return r.artist + " - " + r.title
+	case r.title != "":
+		return r.title
+	}
+	return r.isrc
+}
+
+// importList is what an import file holds: the rows and, for M3U files
+// that give one, a name for the playlist.
+type importList struct {
+	name string
+	rows []importRow
+}
+
+// importFormat picks the format of an import file from its extension.
+func importFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u", ".m3u8":
+		return "m3u"
+	}
+	return "csv"
+}
+
+func readImport(r io.Reader, format string) (*importList, error) {
+	switch format {
+	case "csv":
+		return readImportCSV(r)
+	case "m3u":
+		return readImportM3U(r)
+	}
+	return nil, fmt.Errorf("unknown import format %q, use one of %s", format, strings.Join(importFormats, ", "))
+}
+
+// importColumns are the header names each field is read from, so exports of
+// other services, and of spotify-cli itself, can be read as they are.
+var importColumns = map[string][]string{
+	"artist":      {"artist", "artists", "artist name", "artist name(s)", "creator"},
+	"title":       {"title", "track", "name", "track name", "song"},
+	"isrc":        {"isrc"},
+	"uri":         {"candidate_uri", "uri", "spotify uri", "track uri"},
+	"duration_ms": {"duration_ms", "duration (ms)"},
+	"duration":    {"duration", "length", "time"},
+}
+
+// readImportCSV reads rows from a CSV file with a header row naming at
+// least a title, ISRC or URI column.
+func readImportCSV(r io.Reader) (*importList, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return &importList{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+		for field, names := range importColumns {
+			if _, seen := columns[field]; !seen && slices.Contains(names, name) {
+				columns[field] = i
+			}
+		}
+	}
+	_, hasTitle := columns["title"]
+	_, hasISRC := columns["isrc"]
+	_, hasURI := columns["uri"]
+	if !hasTitle && !hasISRC && !hasURI {
+		return nil, errors.New("the CSV header needs a title, isrc or uri column")
+	}
+
+	list := &importList{}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return list, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := importRow{line: line, artist: field("artist"), title: field("title"), isrc: field("isrc"), uri: field("uri")}
+		if ms, err := strconv.Atoi(field("duration_ms")); err == nil {
+			row.durationMs = ms
+		} else {
+			row.durationMs = parseDuration(field("duration"))
+		}
+		if row.title != "" || row.isrc != "" || row.uri != "" {
+			list.rows = append(list.rows, row)
+		}
+	}
+}
+
+// readImportM3U reads rows from the #EXTINF lines of an M3U playlist, which
+// name tracks as "Artist - Title". Entries without one are named after their
+// file.
+func readImportM3U(r io.Reader) (*importList, error) {
+	list := &importList{}
+	var pending *importRow
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\uFEFF"))
+		switch {
+		case text == "":
+		case strings.HasPrefix(text, "#PLAYLIST:"):
+			list.name = strings.TrimSpace(strings.TrimPrefix(text, "#PLAYLIST:"))
+		case strings.HasPrefix(text, "#EXTINF:"):
+			info := strings.TrimPrefix(text, "#EXTINF:")
+			seconds, name, _ := strings.Cut(info, ",")
+			row := splitArtistTitle(name)
+			row.line = line
+			// Attributes like tvg-id="..." may follow the duration.
+			if secs, err := strconv.Atoi(strings.Fields(seconds + " ")[0]); err == nil && secs > 0 {
+				row.durationMs = secs * 1000
+			}
+			pending = &row
+		case strings.HasPrefix(text, "#"):
+		default:
+			if pending == nil {
+				base := filepath.Base(strings.ReplaceAll(text, `\`, "/"))
+				row := splitArtistTitle(strings.TrimSuffix(base, filepath.Ext(base)))
+				row.line = line
+				pending = &row
+			}
+			if pending.title != "" {
+				list.rows = append(list.rows, *pending)
+			}
+			pending = nil
+		}
+	}
+	return list, scanner.Err()
+}
+
+func splitArtistTitle(s string) importRow {
+	artist, title, ok := strings.Cut(s, " - ")
+	if !ok {
+		return importRow{title: strings.TrimSpace(s)}
+	}
+	return importRow{artist: strings.TrimSpace(artist), title: strings.TrimSpace(title)}
+}
+
+// parseDuration reads durations like "3:25", "1:02:03" or a number of
+// seconds, returning 0 for anything else.
+func parseDuration(s string) int {
+	if s == "" {
+		return 0
+	}
+	total := 0
+	for part := range strings.SplitSeq(s, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0
+		}
+		total = total*60 + n
+	}
+	return total * 1000
+}
+
+// normalizeName simplifies a track or artist name for comparison, dropping
+// what tends to differ between services: case, punctuation, "(Remastered)"
+// and " - Live" style suffixes, and featured artists.
+func normalizeName(s string) string {
+	s = strings.ToLower(s)
+	for _, pair := range [][2]string{{"(", ")"}, {"[", "]"}} {
+		for {
+			start := strings.Index(s, pair[0])
+			end := strings.Index(s, pair[1])
+			if start < 0 || end < start {
+				break
+			}
+			s = s[:start] + " " + s[end+1:]
+		}
+	}
+	if before, _, ok := strings.Cut(s, " - "); ok && strings.TrimSpace(before) != "" {
+		s = before
+	}
+	for _, feat := range []string{" feat. ", " feat ", " ft. ", " featuring "} {
+		if before, _, ok := strings.Cut(s, feat); ok {
+			s = before
+		}
+	}
+	s = strings.ReplaceAll(s, "&", " and ")
+
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}), " ")
+}
+
+// similarity is how alike two normalized names are, from 0 to 1, based on
+// their edit distance.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+// scoreTrack rates how likely t is the track row describes, from 0 to 1. A
+// matching ISRC settles it, otherwise the title counts most, then the
+// artist, and a duration that's off by more than a few seconds counts
+// against it.
+func scoreTrack(row importRow, t Track) float64 {
+	if row.isrc != "" && strings.EqualFold(row.isrc, t.ExternalIds.Isrc) {
+		return 1
+	}
+
+	score := similarity(normalizeName(row.title), normalizeName(t.Name))
+	if row.artist != "" {
+		artist := normalizeName(row.artist)
+		best := similarity(artist, normalizeName(artistNames(t.Artists)))
+		for _, a := range t.Artists {
+			best = max(best, similarity(artist, normalizeName(a.Name)))
+		}
+		score = 0.65*score + 0.35*best
+	} else {
+		// Without an artist, even a perfect title could be anyone's.
+		score -= 0.1
+	}
+
+	if row.durationMs > 0 {
+		off := row.durationMs - t.DurationMs
+		if off < 0 {
+			off = -off
+		}
+		if off > 3000 {
+			score -= min(float64(off)/100000, 0.3)
+		}
+	}
+	return max(score, 0)
+}
+
+type scoredTrack struct {
+	track Track
+	score float64
+}
+
+// sameSong tells whether two candidates are the same recording, like a
+// track that's on both an album and a compilation.
+func sameSong(a, b Track) bool {
+	if a.ExternalIds.Isrc != "" && a.ExternalIds.Isrc == b.ExternalIds.Isrc {
+		return true
+	}
+	return normalizeName(a.Name) == normalizeName(b.Name) && artistNames(a.Artists) == artistNames(b.Artists)
+}
+
+type importStatus string
+
+const (
+	importMatched   importStatus = "matched"
+	importAmbiguous importStatus = "ambiguous"
+	importUnmatched importStatus = "unmatched"
+)
+
+// importResult is what a row resolved to. Candidates are the tracks worth
+// considering, best first.
+type importResult struct {
+	row        importRow
+	status     importStatus
+	candidates []scoredTrack
+}
+
+// quoteFilter quotes a value for a field filter, without the quotes the
+// value may have had, which would end the filter early.
+func quoteFilter(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+}
+
+// importQueries are the searches that may find row, from the most to the
+// least precise. Titles are searched without their "(Remastered)" style
+// suffixes, which the catalog names differently.
+func importQueries(row importRow) []string {
+	var queries []string
+	if row.isrc != "" {
+		queries = append(queries, "isrc:"+row.isrc)
+	}
+	title := normalizeName(row.title)
+	artist := strings.TrimSpace(row.artist)
+	if title != "" && artist != "" {
+		queries = append(queries, "artist:"+quoteFilter(artist)+" track:"+quoteFilter(title))
+	}
+	// A misspelt title still turns up among the artist's tracks.
+	if artist != "" {
+		queries = append(queries, "artist:"+quoteFilter(artist))
+	}
+	if title != "" {
+		queries = append(queries, "track:"+quoteFilter(title))
+	}
+	return queries
+}
+
+// resolveRow takes the track row gives the URI of, or searches for row with
+// each of its queries in turn until one finds tracks, and scores them.
+func resolveRow(ctx context.Context, client *Client, row importRow, market string) (importResult, error) {
+	result := importResult{row: row, status: importUnmatched}
+
+	if id, ok := parseSpotifyID("track", row.uri); ok && row.uri != "" {
+		track, err := client.getTrack(ctx, id, market)
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			result.status = importMatched
+			result.candidates = []scoredTrack{{track: *track, score: 1}}
+			return result, nil
+		case !errors.As(err, &apiErr) || (apiErr.Status != http.StatusNotFound && apiErr.Status != http.StatusBadRequest):
+			return result, err
+		}
+		// A track that doesn't exist is searched for like any other.
+	}
+
+	var tracks []Track
+	for _, q := range importQueries(row) {
+		results, err := client.search(ctx, SearchQuery{Q: q, Type: "track", Market: market, Limit: 20})
+		if err != nil {
+			return result, err
+		}
+		for _, item := range results.Tracks.Items {
+			t := Track{SimplifiedTrack: SimplifiedTrack{ID: item.ID, Name: item.Name, DurationMs: item.DurationMs, URI: item.URI}}
+			for _, a := range item.Artists {
+				t.Artists = append(t.Artists, SimplifiedArtist{ID: a.ID, Name: a.Name})
+			}
+			t.ExternalIds.Isrc = item.ExternalIds.Isrc
+			tracks = append(tracks, t)
+		}
+		if len(tracks) > 0 {
+			break
+		}
+	}
+
+	for _, t := range tracks {
+		if score := scoreTrack(row, t); score >= ambiguousScore {
+			result.candidates = append(result.candidates, scoredTrack{track: t, score: score})
+		}
+	}
+	slices.SortStableFunc(result.candidates, func(a, b scoredTrack) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		}
+		return 0
+	})
+	if len(result.candidates) == 0 {
+		return result, nil
+	}
+
+	best := result.candidates[0]
+	result.status = importMatched
+	if best.score < matchScore {
+		result.status = importAmbiguous
+	}
+	for _, c := range result.candidates[1:] {
+		if !sameSong(best.track, c.track) && best.score-c.score < ambiguousMargin {
+			result.status = importAmbiguous
+		}
+	}
+	return result, nil
+}
+
+func candidateName(t Track) string {
+	return artistNames(t.Artists) + " - " + t.Name
+}
+
+// writeImportReport lists the rows that weren't matched in a CSV that can be
+// fixed up and imported again, one row for each line of the input. The best
+// candidate goes in candidate_uri, which importing takes as the track, and
+// the others are listed after it to pick from.
+func writeImportReport(w io.Writer, results []importResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"line", "status", "artist", "title", "isrc", "duration_ms", "candidate", "candidate_uri", "score", "other_candidates"})
+	for _, r := range results {
+		if r.status == importMatched {
+			continue
+		}
+		record := []string{strconv.Itoa(r.row.line), string(r.status), r.row.artist, r.row.title, r.row.isrc, "", "", "", "", ""}
+		if r.row.durationMs > 0 {
+			record[5] = strconv.Itoa(r.row.durationMs)
+		}
+		if len(r.candidates) > 0 {
+			best := r.candidates[0]
+			record[6] = candidateName(best.track)
+			record[7] = best.track.URI
+			record[8] = strconv.FormatFloat(best.score, 'f', 2, 64)
+			var others []string
+			for _, c := range r.candidates[1:] {
+				others = append(others, fmt.Sprintf("%s (%s, %.2f)", candidateName(c.track), c.track.URI, c.score))
+			}
+			record[9] = strings.Join(others, "; ")
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// importLineError is an error looking up the track on a line of the input.
+type importLineError struct {
+	line int
+	err  error
+}
+
+func (e *importLineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+func (e *importLineError) Unwrap() error {
+	return e.err
+}
+
+func resolveImport(ctx context.Context, client *Client, list *importList, market string) ([]importResult, error) {
+	results := []importResult{}
+	for _, row := range list.rows {
+		result, err := resolveRow(ctx, client, row, market)
+		if err != nil {
+			return nil, &importLineError{line: row.line, err: err}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// matchedURIs are the URIs of the matched rows, in the order of the input.
+func matchedURIs(results []importResult) []string {
+	uris := []string{}
+	for _, r := range results {
+		if r.status == importMatched {
+			uris = append(uris, r.candidates[0].track.URI)
+		}
+	}
+	return uris
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestReadImport(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   []importRow
+	}{
+		{
+			name:   "csv",
+			format: "csv",
+			input: "Track Name,Artist Name,Duration,ISRC\n" +
+				"Lithium,Nirvana,4:17,\n" +
+				"\"Dreams\",Fleetwood Mac,,USWB10400049\n" +
+				",,,\n",
+			want: []importRow{
+				{line: 2, artist: "Nirvana", title: "Lithium", durationMs: 257000},
+				{line: 3, artist: "Fleetwood Mac", title: "Dreams", isrc: "USWB10400049"},
+			},
+		},
+		{
+			name:   "csv export",
+			format: "csv",
+			input: "type,name,artists,album,duration_ms,isrc,added_at,uri,url\n" +
+				"track,Time,Pink Floyd,The Dark Side of the Moon,413000,GBN9Y1100086,,,\n",
+			want: []importRow{
+				{line: 2, artist: "Pink Floyd", title: "Time", isrc: "GBN9Y1100086", durationMs: 413000},
+			},
+		},
+		{
+			name:   "m3u",
+			format: "m3u",
+			input: "#EXTM3U\n" +
+				"#PLAYLIST:Mix\n" +
+				"#EXTINF:257,Nirvana - Lithium\n" +
+				"https://open.spotify.com/track/1\n" +
+				"\n" +
+				"music/Radiohead - Creep.mp3\n" +
+				"#EXTINF:-1,Untitled\n" +
+				"stream.mp3\n",
+			want: []importRow{
+				{line: 3, artist: "Nirvana", title: "Lithium", durationMs: 257000},
+				{line: 6, artist: "Radiohead", title: "Creep"},
+				{line: 7, title: "Untitled"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := readImport(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list.rows) != len(tt.want) {
+				t.Fatalf("got rows %+v, want %+v", list.rows, tt.want)
+			}
+			for i, row := range list.rows {
+				if row != tt.want[i] {
+					t.Errorf("row %d = %+v, want %+v", i, row, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := readImport(strings.NewReader("artist,album\nNirvana,Nevermind\n"), "csv"); err == nil {
+		t.Error("got no error for a CSV without a title or isrc column")
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Go Your Own Way - 2004 Remaster", "go your own way"},
+		{"Wish You Were Here (Remastered) [Live]", "wish you were here"},
+		{"You're My Best Friend", "you're my best friend"},
+		{"Simon & Garfunkel", "simon and garfunkel"},
+		{"Everlong feat. Somebody", "everlong"},
+		{"Breathe (In the Air)", "breathe"},
+	}
+	for _, tt := range tests {
+		if got := normalizeName(tt.name); got != tt.want {
+			t.Errorf("normalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	client, _ := newLoggedInClient(t)
+	ctx := context.Background()
+
+	list, err := readImport(strings.NewReader("artist,title,isrc,duration\n"+
+		",,USGF19942501,\n"+ // Smells Like Teen Spirit
+		"Radiohead,Karma Police,,4:24\n"+
+		"Fleetwood Mac,Go Your Own Way (2004 Remaster),,\n"+
+		"Nirvana,Come As Your Are,,\n"+ // misspelt
+		"Radiohead,Paranoid,,\n"+ // close to Paranoid Android
+		"Daft Punk,One More Time,,\n",
+	), "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := resolveImport(ctx, client, list, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		status importStatus
+		name   string
+	}{
+		{importMatched, "Smells Like Teen Spirit"},
+		{importMatched, "Karma Police"},
+		{importMatched, "Go Your Own Way"},
+		{importMatched, "Come As You Are"},
+		{importAmbiguous, "Paranoid Android"},
+		{importUnmatched, ""},
+	}
+	for i, r := range results {
+		var name string
+		if len(r.candidates) > 0 {
+			name = r.candidates[0].track.Name
+		}
+		if r.status != want[i].status || name != want[i].name {
+			t.Errorf("line %d: got %s %q, want %s %q", r.row.line, r.status, name, want[i].status, want[i].name)
+		}
+	}
+
+	var report bytes.Buffer
+	if err := writeImportReport(&report, results); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "6,ambiguous,Radiohead,Paranoid,,,Radiohead - Paranoid Android,spotify:track:") ||
+		lines[2] != "7,unmatched,Daft Punk,One More Time,,,,,," {
+		t.Errorf("got report\n%s", report.String())
+	}
+
+	// The report can be imported again as it is, which takes the candidates
+	// it gives.
+	again, err := readImport(&report, "csv")
+	if err != nil || len(again.rows) != 2 || again.rows[1].title != "One More Time" {
+		t.Fatalf("got rows %+v, %v reading the report", again, err)
+	}
+	reimported, err := resolveImport(ctx, client, again, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := reimported[0]; r.status != importMatched || r.candidates[0].track.Name != "Paranoid Android" {
+		t.Errorf("got %s %+v for the report's candidate, want it matched", r.status, r.candidates)
+	}
+	if r := reimported[1]; r.status != importUnmatched {
+		t.Errorf("got %s for the row without a candidate, want it unmatched", r.status)
+	}
+	gone, err := resolveRow(ctx, client, importRow{line: 2, artist: "Nirvana", title: "Lithium", uri: "spotify:track:gone"}, "")
+	if err != nil || gone.status != importMatched || gone.candidates[0].track.Name != "Lithium" {
+		t.Errorf("got %s %+v and %v, want a track that's gone searched for instead", gone.status, gone.candidates, err)
+	}
+
+	uris := matchedURIs(results)
+	if len(uris) != 4 {
+		t.Fatalf("got %d matched URIs, want 4", len(uris))
+	}
+	playlist, err := client.createPlaylist(ctx, PlaylistDetails{Name: "Imported"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.addToPlaylist(ctx, playlist.ID, uris, -1); err != nil {
+		t.Fatal(err)
+	}
+	export, err := fetchPlaylistExport(ctx, client, playlist.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := export.rows(); len(got) != 4 || got[3].Name != "Come As You Are" {
+		t.Errorf("got playlist rows %+v", got)
+	}
+}
@@ -0,0 +1,499 @@
+package fakespotify
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type artistRef struct {
+	id string
+	*artist
+}
+
+type albumRef struct {
+	id string
+	*album
+	artist *artistRef
+}
+
+type trackRef struct {
+	id string
+	*track
+	album *albumRef
+}
+
+type playlistRef struct {
+	id string
+	*playlist
+	items []*trackRef
+}
+
+type showRef struct {
+	id string
+	*show
+	items []*episodeRef
+}
+
+type episodeRef struct {
+	id string
+	*episode
+	show *showRef
+}
+
+type audiobookRef struct {
+	id string
+	*audiobook
+	items []*chapterRef
+}
+
+type chapterRef struct {
+	id     string
+	number int
+	*chapter
+	book *audiobookRef
+}
+
+func (r *artistRef) ident() string    { return r.id }
+func (r *albumRef) ident() string     { return r.id }
+func (r *trackRef) ident() string     { return r.id }
+func (r *playlistRef) ident() string  { return r.id }
+func (r *showRef) ident() string      { return r.id }
+func (r *episodeRef) ident() string   { return r.id }
+func (r *audiobookRef) ident() string { return r.id }
+
+var (
+	catalogArtists    []*artistRef
+	catalogAlbums     []*albumRef
+	catalogTracks     []*trackRef
+	catalogPlaylists  []*playlistRef
+	catalogShows      []*showRef
+	catalogEpisodes   []*episodeRef
+	catalogAudiobooks []*audiobookRef
+)
+
+func init() {
+	tracksByName := map[string]*trackRef{}
+
+	for i := range artists {
+		ar := &artistRef{id: spotifyID("artist", artists[i].name), artist: &artists[i]}
+		catalogArtists = append(catalogArtists, ar)
+		for j := range ar.albums {
+			al := &albumRef{id: spotifyID("album", ar.name+"/"+ar.albums[j].name), album: &ar.albums[j], artist: ar}
+			catalogAlbums = append(catalogAlbums, al)
+			for k := range al.tracks {
+				t := &trackRef{id: spotifyID("track", ar.name+"/"+al.tracks[k].name), track: &al.tracks[k], album: al}
+				catalogTracks = append(catalogTracks, t)
+				tracksByName[t.name] = t
+			}
+		}
+	}
+
+	for i := range playlists {
+		p := &playlistRef{id: spotifyID("playlist", playlists[i].name), playlist: &playlists[i]}
+		for _, name := range p.tracks {
+			p.items = append(p.items, tracksByName[name])
+		}
+		catalogPlaylists = append(catalogPlaylists, p)
+	}
+
+	for i := range shows {
+		sh := &showRef{id: spotifyID("show", shows[i].name), show: &shows[i]}
+		for j := range sh.episodes {
+			e := &episodeRef{id: spotifyID("episode", sh.name+"/"+sh.episodes[j].name), episode: &sh.episodes[j], show: sh}
+			sh.items = append(sh.items, e)
+			catalogEpisodes = append(catalogEpisodes, e)
+		}
+		catalogShows = append(catalogShows, sh)
+	}
+
+	for i := range audiobooks {
+		a := &audiobookRef{id: spotifyID("audiobook", audiobooks[i].name), audiobook: &audiobooks[i]}
+		for j := range a.chapters {
+			a.items = append(a.items, &chapterRef{
+				id:      spotifyID("chapter", a.name+"/"+a.chapters[j].name),
+				number:  j,
+				chapter: &a.chapters[j],
+				book:    a,
+			})
+		}
+		catalogAudiobooks = append(catalogAudiobooks, a)
+	}
+}
+
+func findByID[T interface{ ident() string }](items []T, id string) (T, bool) {
+	for _, item := range items {
+		if item.ident() == id {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func topTracks(a *artistRef) []*trackRef {
+	var tracks []*trackRef
+	for _, t := range catalogTracks {
+		if t.album.artist == a {
+			tracks = append(tracks, t)
+		}
+	}
+	slices.SortStableFunc(tracks, func(x, y *trackRef) int {
+		return y.popularity - x.popularity
+	})
+	return tracks[:min(len(tracks), 10)]
+}
+
+type searchQuery struct {
+	terms   []string
+	filters map[string]string
+}
+
+var filterFields = []string{"artist", "album", "track", "year", "genre", "isrc", "upc", "tag"}
+
+// parseQuery splits a search query into free-text terms and field filters
+// such as artist:"pink floyd" or year:1970-1979.
+func parseQuery(q string) searchQuery {
+	sq := searchQuery{filters: map[string]string{}}
+
+	var tokens []string
+	var cur strings.Builder
+	inQuotes := false
+	for _, r := range strings.ToLower(q) {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ' ' && !inQuotes:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+
+	for _, tok := range tokens {
+		field, value, ok := strings.Cut(tok, ":")
+		if ok && slices.Contains(filterFields, field) {
+			sq.filters[field] = value
+		} else {
+			sq.terms = append(sq.terms, tok)
+		}
+	}
+
+	return sq
+}
+
+func contains(haystack, needle string) bool {
+	return strings.Contains(strings.ToLower(haystack), needle)
+}
+
+func inYears(date, years string) bool {
+	if len(date) < 4 {
+		return false
+	}
+	year, _ := strconv.Atoi(date[:4])
+	from, to, isRange := strings.Cut(years, "-")
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return false
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.Atoi(to); err != nil {
+			return false
+		}
+	}
+	return year >= start && year <= end
+}
+
+func (q searchQuery) termsIn(fields ...string) bool {
+	for _, term := range q.terms {
+		found := false
+		for _, f := range fields {
+			if contains(f, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (q searchQuery) matchText(fields ...string) bool {
+	return len(q.filters) == 0 && len(q.terms) > 0 && q.termsIn(fields...)
+}
+
+func (q searchQuery) matchArtist(a *artistRef) bool {
+	for field, value := range q.filters {
+		switch field {
+		case "artist":
+			if !contains(a.name, value) {
+				return false
+			}
+		case "genre":
+			if !slices.ContainsFunc(a.genres, func(g string) bool { return contains(g, value) }) {
+				return false
+			}
+		case "year":
+			if !slices.ContainsFunc(a.albums, func(al album) bool { return inYears(al.releaseDate, value) }) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return q.termsIn(a.name)
+}
+
+func (q searchQuery) matchAlbum(a *albumRef) bool {
+	for field, value := range q.filters {
+		switch field {
+		case "artist":
+			if !contains(a.artist.name, value) {
+				return false
+			}
+		case "album":
+			if !contains(a.name, value) {
+				return false
+			}
+		case "year":
+			if !inYears(a.releaseDate, value) {
+				return false
+			}
+		case "upc":
+			if a.upc != value {
+				return false
+			}
+		default:
+			// tag:new and tag:hipster never match the fixed catalog.
+			return false
+		}
+	}
+	return q.termsIn(a.name, a.artist.name)
+}
+
+func (q searchQuery) matchTrack(t *trackRef) bool {
+	for field, value := range q.filters {
+		switch field {
+		case "artist":
+			if !contains(t.album.artist.name, value) {
+				return false
+			}
+		case "album":
+			if !contains(t.album.name, value) {
+				return false
+			}
+		case "track":
+			if !contains(t.name, value) {
+				return false
+			}
+		case "year":
+			if !inYears(t.album.releaseDate, value) {
+				return false
+			}
+		case "genre":
+			if !slices.ContainsFunc(t.album.artist.genres, func(g string) bool { return contains(g, value) }) {
+				return false
+			}
+		case "isrc":
+			if !strings.EqualFold(t.isrc, value) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return q.termsIn(t.name, t.album.artist.name, t.album.name)
+}
+
+func (s *Server) object(kind, id string) map[string]any {
+	return map[string]any{
+		"id":   id,
+		"type": kind,
+		"uri":  "spotify:" + kind + ":" + id,
+		"href": s.URL + "/v1/" + kind + "s/" + id,
+		"external_urls": map[string]any{
+			"spotify": "https://open.spotify.com/" + kind + "/" + id,
+		},
+	}
+}
+
+func images(id string) []any {
+	var imgs []any
+	for _, size := range []int{640, 300, 64} {
+		imgs = append(imgs, map[string]any{
+			"url":    "https://i.scdn.co/image/" + id + "-" + strconv.Itoa(size),
+			"height": size,
+			"width":  size,
+		})
+	}
+	return imgs
+}
+
+func (s *Server) simpleArtist(a *artistRef) map[string]any {
+	o := s.object("artist", a.id)
+	o["name"] = a.name
+	return o
+}
+
+func (s *Server) fullArtist(a *artistRef) map[string]any {
+	o := s.simpleArtist(a)
+	o["genres"] = a.genres
+	o["popularity"] = a.popularity
+	o["followers"] = map[string]any{"href": nil, "total": a.followers}
+	o["images"] = images(a.id)
+	return o
+}
+
+func (s *Server) simpleAlbum(a *albumRef) map[string]any {
+	o := s.object("album", a.id)
+	o["name"] = a.name
+	o["album_type"] = a.albumType
+	o["total_tracks"] = len(a.tracks)
+	o["release_date"] = a.releaseDate
+	o["release_date_precision"] = "day"
+	o["available_markets"] = markets
+	o["images"] = images(a.id)
+	o["artists"] = []any{s.simpleArtist(a.artist)}
+	return o
+}
+
+func (s *Server) fullAlbum(r *http.Request, a *albumRef) map[string]any {
+	o := s.simpleAlbum(a)
+	o["label"] = a.label
+	o["popularity"] = a.tracks[0].popularity
+	o["external_ids"] = map[string]any{"upc": a.upc}
+	o["copyrights"] = []any{
+		map[string]any{"text": a.releaseDate[:4] + " " + a.label, "type": "C"},
+		map[string]any{"text": a.releaseDate[:4] + " " + a.label, "type": "P"},
+	}
+
+	var tracks []any
+	for _, t := range catalogTracks {
+		if t.album == a {
+			tracks = append(tracks, s.simpleTrack(t))
+		}
+	}
+	page, _ := s.paginate(&http.Request{URL: r.URL.JoinPath("tracks")}, tracks, 50, 50)
+	o["tracks"] = page
+	return o
+}
+
+func (s *Server) simpleTrack(t *trackRef) map[string]any {
+	o := s.object("track", t.id)
+	o["name"] = t.name
+	o["duration_ms"] = t.durationMs
+	o["explicit"] = t.explicit
+	o["disc_number"] = 1
+	o["track_number"] = slices.IndexFunc(t.album.tracks, func(x track) bool { return x.name == t.name }) + 1
+	o["is_local"] = false
+	o["is_playable"] = true
+	o["available_markets"] = markets
+	o["artists"] = []any{s.simpleArtist(t.album.artist)}
+	return o
+}
+
+func (s *Server) fullTrack(t *trackRef) map[string]any {
+	o := s.simpleTrack(t)
+	o["album"] = s.simpleAlbum(t.album)
+	o["popularity"] = t.popularity
+	o["external_ids"] = map[string]any{"isrc": t.isrc}
+	return o
+}
+
+func (s *Server) simplePlaylist(p *playlistRef) map[string]any {
+	o := s.object("playlist", p.id)
+	o["name"] = p.name
+	o["description"] = p.description
+	o["collaborative"] = false
+	o["public"] = true
+	o["snapshot_id"] = spotifyID("snapshot", p.name)
+	o["images"] = images(p.id)
+	owner := s.object("user", strings.ToLower(strings.ReplaceAll(p.owner, " ", "")))
+	owner["display_name"] = p.owner
+	o["owner"] = owner
+	o["tracks"] = map[string]any{
+		"href":  s.URL + "/v1/playlists/" + p.id + "/tracks",
+		"total": len(p.items),
+	}
+	return o
+}
+
+func (s *Server) simpleShow(sh *showRef) map[string]any {
+	o := s.object("show", sh.id)
+	o["name"] = sh.name
+	o["publisher"] = sh.publisher
+	o["description"] = sh.description
+	o["html_description"] = "<p>" + sh.description + "</p>"
+	o["explicit"] = false
+	o["is_externally_hosted"] = false
+	o["languages"] = []string{"en"}
+	o["media_type"] = "audio"
+	o["total_episodes"] = len(sh.items)
+	o["available_markets"] = markets
+	o["images"] = images(sh.id)
+	return o
+}
+
+func (s *Server) simpleEpisode(e *episodeRef) map[string]any {
+	o := s.object("episode", e.id)
+	o["name"] = e.name
+	o["description"] = e.description
+	o["html_description"] = "<p>" + e.description + "</p>"
+	o["duration_ms"] = e.durationMs
+	o["explicit"] = false
+	o["is_playable"] = true
+	o["language"] = "en"
+	o["languages"] = []string{"en"}
+	o["release_date"] = e.releaseDate
+	o["release_date_precision"] = "day"
+	o["images"] = images(e.id)
+	return o
+}
+
+func (s *Server) simpleAudiobook(a *audiobookRef) map[string]any {
+	o := s.object("audiobook", a.id)
+	o["name"] = a.name
+	o["publisher"] = a.publisher
+	o["description"] = a.name + " by " + strings.Join(a.authors, ", ")
+	o["explicit"] = false
+	o["languages"] = []string{"en"}
+	o["media_type"] = "audio"
+	o["total_chapters"] = len(a.items)
+	o["available_markets"] = markets
+	o["images"] = images(a.id)
+
+	var authors, narrators []any
+	for _, name := range a.authors {
+		authors = append(authors, map[string]any{"name": name})
+	}
+	for _, name := range a.narrators {
+		narrators = append(narrators, map[string]any{"name": name})
+	}
+	o["authors"] = authors
+	o["narrators"] = narrators
+	return o
+}
+
+func (s *Server) simpleChapter(c *chapterRef) map[string]any {
+	o := s.object("chapter", c.id)
+	o["name"] = c.name
+	o["chapter_number"] = c.number
+	o["duration_ms"] = c.durationMs
+	o["description"] = c.name + " of " + c.book.name
+	o["explicit"] = false
+	o["is_playable"] = true
+	o["images"] = images(c.id)
+	return o
+}
+
+var markets = []string{"AU", "BR", "CA", "DE", "ES", "FR", "GB", "IE", "IT", "JP", "MX", "NL", "NZ", "SE", "US"}
@@ -0,0 +1,293 @@
+package fakespotify
+
+import (
+	"crypto/sha256"
+	"math/big"
+)
+
+type artist struct {
+	name       string
+	genres     []string
+	followers  int
+	popularity int
+	albums     []album
+}
+
+type album struct {
+	name        string
+	albumType   string
+	releaseDate string
+	label       string
+	upc         string
+	tracks      []track
+}
+
+type track struct {
+	name       string
+	durationMs int
+	isrc       string
+	explicit   bool
+	popularity int
+}
+
+type playlist struct {
+	name        string
+	owner       string
+	description string
+	tracks      []string
+}
+
+type show struct {
+	name        string
+	publisher   string
+	description string
+	episodes    []episode
+}
+
+type episode struct {
+	name        string
+	description string
+	durationMs  int
+	releaseDate string
+}
+
+type audiobook struct {
+	name      string
+	authors   []string
+	narrators []string
+	publisher string
+	chapters  []chapter
+}
+
+type chapter struct {
+	name       string
+	durationMs int
+}
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// spotifyID derives a stable, realistic looking 22 character base62 ID from
+// the kind and name of an object.
+func spotifyID(kind, name string) string {
+	sum := sha256.Sum256([]byte(kind + ":" + name))
+	n := new(big.Int).SetBytes(sum[:])
+	id := make([]byte, 22)
+	mod := new(big.Int)
+	radix := big.NewInt(62)
+	for i := range id {
+		n.DivMod(n, radix, mod)
+		id[i] = base62[mod.Int64()]
+	}
+	return string(id)
+}
+
+var artists = []artist{
+	{
+		name:       "Nirvana",
+		genres:     []string{"grunge", "alternative rock", "rock"},
+		followers:  21304987,
+		popularity: 80,
+		albums: []album{
+			{
+				name: "Nevermind", albumType: "album", releaseDate: "1991-09-24", label: "DGC", upc: "720642442524",
+				tracks: []track{
+					{"Smells Like Teen Spirit", 301920, "USGF19942501", false, 86},
+					{"In Bloom", 254800, "USGF19942502", false, 74},
+					{"Come As You Are", 218920, "USGF19942503", false, 81},
+					{"Lithium", 256880, "USGF19942505", false, 75},
+				},
+			},
+			{
+				name: "In Utero", albumType: "album", releaseDate: "1993-09-21", label: "DGC", upc: "720642453629",
+				tracks: []track{
+					{"Heart-Shaped Box", 281160, "USGF19463502", false, 76},
+					{"Dumb", 152360, "USGF19463506", false, 68},
+					{"Pennyroyal Tea", 217106, "USGF19463509", false, 66},
+					{"All Apologies", 231440, "USGF19463512", false, 72},
+				},
+			},
+		},
+	},
+	{
+		name:       "Radiohead",
+		genres:     []string{"alternative rock", "art rock", "permanent wave"},
+		followers:  10928743,
+		popularity: 79,
+		albums: []album{
+			{
+				name: "Pablo Honey", albumType: "album", releaseDate: "1993-02-22", label: "XL Recordings", upc: "634904078102",
+				tracks: []track{
+					{"You", 208760, "GBAYE9200070", false, 58},
+					{"Creep", 238640, "GBAYE9200113", true, 88},
+					{"Anyone Can Play Guitar", 218293, "GBAYE9200071", false, 52},
+				},
+			},
+			{
+				name: "The Bends", albumType: "album", releaseDate: "1995-03-13", label: "XL Recordings", upc: "634904078201",
+				tracks: []track{
+					{"High and Dry", 257826, "GBAYE9400673", false, 76},
+					{"Fake Plastic Trees", 290173, "GBAYE9400674", false, 74},
+					{"Street Spirit (Fade Out)", 253600, "GBAYE9400683", false, 65},
+				},
+			},
+			{
+				name: "OK Computer", albumType: "album", releaseDate: "1997-05-21", label: "XL Recordings", upc: "634904078300",
+				tracks: []track{
+					{"Airbag", 284360, "GBAYE9700378", false, 63},
+					{"Paranoid Android", 387000, "GBAYE9700379", false, 70},
+					{"Karma Police", 264066, "GBAYE9700384", false, 78},
+					{"No Surprises", 229120, "GBAYE9700388", false, 80},
+				},
+			},
+		},
+	},
+	{
+		name:       "Pink Floyd",
+		genres:     []string{"progressive rock", "psychedelic rock", "classic rock"},
+		followers:  20123554,
+		popularity: 78,
+		albums: []album{
+			{
+				name: "The Dark Side of the Moon", albumType: "album", releaseDate: "1973-03-01", label: "Pink Floyd Records", upc: "5099902987729",
+				tracks: []track{
+					{"Breathe (In the Air)", 169533, "GBN9Y1100085", false, 68},
+					{"Time", 413813, "GBN9Y1100087", false, 74},
+					{"Money", 382840, "GBN9Y1100089", false, 72},
+					{"Us and Them", 469560, "GBN9Y1100090", false, 66},
+				},
+			},
+			{
+				name: "Wish You Were Here", albumType: "album", releaseDate: "1975-09-12", label: "Pink Floyd Records", upc: "5099902988030",
+				tracks: []track{
+					{"Shine On You Crazy Diamond (Pts. 1-5)", 811077, "GBN9Y1100094", false, 63},
+					{"Wish You Were Here", 334743, "GBN9Y1100097", false, 79},
+				},
+			},
+		},
+	},
+	{
+		name:       "Queen",
+		genres:     []string{"classic rock", "glam rock", "rock"},
+		followers:  52340876,
+		popularity: 85,
+		albums: []album{
+			{
+				name: "A Night At The Opera", albumType: "album", releaseDate: "1975-11-21", label: "EMI", upc: "00602547202703",
+				tracks: []track{
+					{"You're My Best Friend", 170933, "GBUM71029605", false, 70},
+					{"Love Of My Life", 218360, "GBUM71029606", false, 72},
+					{"Bohemian Rhapsody", 354320, "GBUM71029604", false, 89},
+				},
+			},
+			{
+				name: "News Of The World", albumType: "album", releaseDate: "1977-10-28", label: "EMI", upc: "00602547202734",
+				tracks: []track{
+					{"We Will Rock You", 122066, "GBUM71029615", false, 84},
+					{"We Are The Champions", 179200, "GBUM71029616", false, 82},
+				},
+			},
+		},
+	},
+	{
+		name:       "Fleetwood Mac",
+		genres:     []string{"soft rock", "classic rock", "album rock"},
+		followers:  13250998,
+		popularity: 81,
+		albums: []album{
+			{
+				name: "Rumours", albumType: "album", releaseDate: "1977-02-04", label: "Rhino/Warner Records", upc: "081227979423",
+				tracks: []track{
+					{"Dreams", 257800, "USWB10400049", false, 87},
+					{"Don't Stop", 193346, "USWB10400048", false, 75},
+					{"Go Your Own Way", 223613, "USWB10400050", false, 80},
+					{"The Chain", 270213, "USWB10400054", false, 79},
+				},
+			},
+		},
+	},
+	{
+		name:       "Foo Fighters",
+		genres:     []string{"alternative rock", "post-grunge", "rock"},
+		followers:  12875002,
+		popularity: 77,
+		albums: []album{
+			{
+				name: "The Colour And The Shape", albumType: "album", releaseDate: "1997-05-20", label: "RCA Records Label", upc: "886445452394",
+				tracks: []track{
+					{"Monkey Wrench", 231066, "USRW29600011", false, 69},
+					{"My Hero", 260026, "USRW29600012", false, 74},
+					{"Everlong", 250546, "USRW29600007", false, 83},
+				},
+			},
+		},
+	},
+}
+
+var playlists = []playlist{
+	{
+		name:        "90s Alternative Anthems",
+		owner:       "Spotify",
+		description: "The biggest alternative hits of the 1990s.",
+		tracks:      []string{"Smells Like Teen Spirit", "Creep", "Everlong", "Karma Police", "Heart-Shaped Box", "High and Dry", "My Hero"},
+	},
+	{
+		name:        "Classic Rock Essentials",
+		owner:       "Spotify",
+		description: "Timeless rock from the 70s.",
+		tracks:      []string{"Bohemian Rhapsody", "Dreams", "Wish You Were Here", "Money", "Go Your Own Way", "We Will Rock You"},
+	},
+	{
+		name:        "Grunge Forever",
+		owner:       "Rock Archive",
+		description: "Flannel, fuzz and feedback.",
+		tracks:      []string{"Lithium", "In Bloom", "Come As You Are", "Dumb", "All Apologies", "Monkey Wrench"},
+	},
+}
+
+var shows = []show{
+	{
+		name:        "Song Exploder",
+		publisher:   "Hrishikesh Hirway",
+		description: "Musicians take apart their songs and piece by piece tell the story of how they were made.",
+		episodes: []episode{
+			{"Fleetwood Mac - Dreams", "The story behind a timeless single.", 1104000, "2020-10-16"},
+			{"Foo Fighters - Everlong", "Dave Grohl on writing Everlong.", 1275000, "2021-06-04"},
+			{"Radiohead - Creep", "How a B-side became a career.", 982000, "2019-11-22"},
+		},
+	},
+	{
+		name:        "Switched on Pop",
+		publisher:   "Vulture",
+		description: "A podcast about the making and meaning of popular music.",
+		episodes: []episode{
+			{"Why Bohemian Rhapsody Still Rules", "Breaking down Queen's operatic epic.", 2412000, "2018-11-02"},
+			{"The Dark Side of the Moon at 50", "Revisiting Pink Floyd's landmark album.", 2988000, "2023-03-01"},
+		},
+	},
+}
+
+var audiobooks = []audiobook{
+	{
+		name:      "Just Kids",
+		authors:   []string{"Patti Smith"},
+		narrators: []string{"Patti Smith"},
+		publisher: "HarperAudio",
+		chapters: []chapter{
+			{"Monday's Children", 3312000},
+			{"Just Kids", 12480000},
+			{"Hotel Chelsea", 9945000},
+		},
+	},
+	{
+		name:      "Life",
+		authors:   []string{"Keith Richards", "James Fox"},
+		narrators: []string{"Johnny Depp", "Joe Hurley", "Keith Richards"},
+		publisher: "Hachette Audio",
+		chapters: []chapter{
+			{"Chapter One", 2710000},
+			{"Chapter Two", 3120000},
+			{"Chapter Three", 2895000},
+			{"Chapter Four", 3301000},
+		},
+	},
+}
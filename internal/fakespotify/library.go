@@ -0,0 +1,160 @@
+package fakespotify
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// saved is an item in one of the fake user's library collections.
+type saved struct {
+	id      string
+	addedAt time.Time
+}
+
+// library holds the fake user's saved items per collection ("tracks",
+// "albums", "shows", "episodes" and "audiobooks"), most recently saved first.
+type library map[string][]saved
+
+// defaultLibrary saves every track, so paging through them takes a few
+// requests, and a few of everything else.
+func defaultLibrary() library {
+	lib := library{}
+	added := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	save := func(collection, id string) {
+		lib[collection] = append(lib[collection], saved{id: id, addedAt: added})
+		added = added.Add(-24 * time.Hour)
+	}
+
+	for _, t := range catalogTracks {
+		save("tracks", t.id)
+	}
+	for _, a := range catalogAlbums[:3] {
+		save("albums", a.id)
+	}
+	save("shows", catalogShows[0].id)
+	for _, e := range catalogShows[0].items[:2] {
+		save("episodes", e.id)
+	}
+	save("audiobooks", catalogAudiobooks[0].id)
+	return lib
+}
+
+// savedJSON returns the object for a saved item the way its collection
+// lists it, or nil when it's not in the catalog.
+func (s *Server) savedJSON(collection string, item saved) any {
+	added := item.addedAt.Format(time.RFC3339)
+	switch collection {
+	case "tracks":
+		if t, ok := findByID(catalogTracks, item.id); ok {
+			return map[string]any{"added_at": added, "track": s.fullTrack(t)}
+		}
+	case "albums":
+		if a, ok := findByID(catalogAlbums, item.id); ok {
+			return map[string]any{"added_at": added, "album": s.simpleAlbum(a)}
+		}
+	case "shows":
+		if sh, ok := findByID(catalogShows, item.id); ok {
+			return map[string]any{"added_at": added, "show": s.simpleShow(sh)}
+		}
+	case "episodes":
+		if e, ok := findByID(catalogEpisodes, item.id); ok {
+			o := s.simpleEpisode(e)
+			o["show"] = s.simpleShow(e.show)
+			return map[string]any{"added_at": added, "episode": o}
+		}
+	case "audiobooks":
+		// Saved audiobooks are listed as they are, without the added_at
+		// wrapper of the other collections.
+		if a, ok := findByID(catalogAudiobooks, item.id); ok {
+			return s.simpleAudiobook(a)
+		}
+	}
+	return nil
+}
+
+// libraryHandler lists one of the user's library collections.
+func (s *Server) libraryHandler(collection string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		items := []any{}
+		for _, item := range s.library[collection] {
+			if o := s.savedJSON(collection, item); o != nil {
+				items = append(items, o)
+			}
+		}
+		s.writePage(w, r, items, 20, 50)
+	}
+}
+
+// libraryIDs parses the ids of a request to change or check collection,
+// writing an error when there are none or too many.
+func libraryIDs(w http.ResponseWriter, r *http.Request, collection string) ([]string, bool) {
+	maxIDs := 50
+	if collection == "albums" {
+		maxIDs = 20
+	}
+
+	ids := strings.Split(r.URL.Query().Get("ids"), ",")
+	switch {
+	case ids[0] == "":
+		writeError(w, http.StatusBadRequest, "Missing required field: ids")
+		return nil, false
+	case len(ids) > maxIDs:
+		writeError(w, http.StatusBadRequest, "Too many ids requested")
+		return nil, false
+	}
+	return ids, true
+}
+
+// userHandler wraps an endpoint that acts on the fake user's account, which
+// needs a user token.
+func (s *Server) userHandler(h func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request, user bool) {
+	return func(w http.ResponseWriter, r *http.Request, user bool) {
+		if !user {
+			writeError(w, http.StatusUnauthorized, "Valid user authentication required")
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		h(w, r)
+	}
+}
+
+// saveHandler adds items to collection, or removes them when remove is set.
+// Saving an item again moves it to the top.
+func (s *Server) saveHandler(collection string, remove bool) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ids, ok := libraryIDs(w, r, collection)
+		if !ok {
+			return
+		}
+
+		items := slices.DeleteFunc(s.library[collection], func(item saved) bool {
+			return slices.Contains(ids, item.id)
+		})
+		if !remove {
+			now := time.Now().UTC()
+			for _, id := range ids {
+				items = slices.Insert(items, 0, saved{id: id, addedAt: now})
+			}
+		}
+		s.library[collection] = items
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *Server) containsHandler(collection string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ids, ok := libraryIDs(w, r, collection)
+		if !ok {
+			return
+		}
+
+		contains := make([]bool, len(ids))
+		for i, id := range ids {
+			contains[i] = slices.ContainsFunc(s.library[collection], func(item saved) bool { return item.id == id })
+		}
+		writeJSON(w, http.StatusOK, contains)
+	}
+}
@@ -0,0 +1,484 @@
+package fakespotify
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type device struct {
+	id     string
+	name   string
+	kind   string
+	volume int
+}
+
+func defaultDevices() []*device {
+	var devices []*device
+	for _, d := range []struct{ name, kind string }{
+		{"Living Room", "Speaker"},
+		{"Work Laptop", "Computer"},
+		{"Phone", "Smartphone"},
+	} {
+		devices = append(devices, &device{id: DeviceID(d.name), name: d.name, kind: d.kind, volume: 50})
+	}
+	return devices
+}
+
+// DeviceID returns the ID of one of the fake user's devices: "Living Room",
+// "Work Laptop" or "Phone".
+func DeviceID(name string) string {
+	return spotifyID("device", name)
+}
+
+// playerItem is a track, episode or chapter the player can play.
+type playerItem interface {
+	ident() string
+}
+
+func (r *chapterRef) ident() string { return r.id }
+
+func itemDuration(item playerItem) time.Duration {
+	switch it := item.(type) {
+	case *trackRef:
+		return time.Duration(it.durationMs) * time.Millisecond
+	case *episodeRef:
+		return time.Duration(it.durationMs) * time.Millisecond
+	case *chapterRef:
+		return time.Duration(it.durationMs) * time.Millisecond
+	}
+	return 0
+}
+
+// player is the playback state shared by all devices of the fake user. Only
+// one device is active at a time, and none until playback is transferred to
+// one or started on it.
+type player struct {
+	devices  []*device
+	active   *device
+	context  string
+	items    []playerItem
+	queue    []playerItem
+	index    int
+	playing  bool
+	position time.Duration
+	since    time.Time
+	shuffle  bool
+	repeat   string
+}
+
+func (p *player) progress() time.Duration {
+	if len(p.items) == 0 {
+		return 0
+	}
+	pos := p.position
+	if p.playing {
+		pos += time.Since(p.since)
+	}
+	return min(pos, itemDuration(p.items[p.index]))
+}
+
+func (p *player) seek(pos time.Duration) {
+	p.position = pos
+	p.since = time.Now()
+}
+
+func (p *player) setPlaying(playing bool) {
+	p.seek(p.progress())
+	p.playing = playing && len(p.items) > 0
+}
+
+// skip moves delta items forward or back. Queued items play before the
+// rest of the context.
+func (p *player) skip(delta int) {
+	if delta > 0 && len(p.queue) > 0 && p.repeat != "track" {
+		p.items = slices.Insert(p.items, p.index+1, p.queue[0])
+		p.queue = p.queue[1:]
+	}
+
+	switch {
+	case len(p.items) == 0:
+		return
+	case p.repeat == "track":
+	case p.index+delta >= len(p.items) && p.repeat == "context":
+		p.index = 0
+	case p.index+delta >= len(p.items):
+		p.index = len(p.items) - 1
+		p.playing = false
+		p.seek(itemDuration(p.items[p.index]))
+		return
+	default:
+		p.index = max(p.index+delta, 0)
+	}
+	p.seek(0)
+}
+
+func (p *player) findDevice(id string) *device {
+	for _, d := range p.devices {
+		if d.id == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// uriKind returns the object type of a spotify: URI, like "album".
+func uriKind(uri string) string {
+	parts := strings.Split(uri, ":")
+	if len(parts) != 3 || parts[0] != "spotify" {
+		return ""
+	}
+	return parts[1]
+}
+
+// resolveURI returns what playing uri means: the item itself, or the items
+// of an album, artist, playlist, show or audiobook context.
+func resolveURI(uri string) ([]playerItem, bool) {
+	kind, id := uriKind(uri), uri[strings.LastIndex(uri, ":")+1:]
+
+	var items []playerItem
+	switch kind {
+	case "track":
+		if t, ok := findByID(catalogTracks, id); ok {
+			items = append(items, t)
+		}
+	case "episode":
+		if e, ok := findByID(catalogEpisodes, id); ok {
+			items = append(items, e)
+		}
+	case "chapter":
+		for _, a := range catalogAudiobooks {
+			if c, ok := findByID(a.items, id); ok {
+				items = append(items, c)
+			}
+		}
+	case "album":
+		if a, ok := findByID(catalogAlbums, id); ok {
+			for _, t := range catalogTracks {
+				if t.album == a {
+					items = append(items, t)
+				}
+			}
+		}
+	case "artist":
+		if a, ok := findByID(catalogArtists, id); ok {
+			for _, t := range topTracks(a) {
+				items = append(items, t)
+			}
+		}
+	case "playlist":
+		if p, ok := findByID(catalogPlaylists, id); ok {
+			for _, t := range p.items {
+				items = append(items, t)
+			}
+		}
+	case "show":
+		if sh, ok := findByID(catalogShows, id); ok {
+			for _, e := range sh.items {
+				items = append(items, e)
+			}
+		}
+	case "audiobook":
+		if a, ok := findByID(catalogAudiobooks, id); ok {
+			for _, c := range a.items {
+				items = append(items, c)
+			}
+		}
+	}
+	return items, len(items) > 0
+}
+
+func itemURI(item playerItem) string {
+	switch item.(type) {
+	case *trackRef:
+		return "spotify:track:" + item.ident()
+	case *episodeRef:
+		return "spotify:episode:" + item.ident()
+	default:
+		return "spotify:chapter:" + item.ident()
+	}
+}
+
+func (s *Server) playerItemJSON(item playerItem) (map[string]any, string) {
+	switch it := item.(type) {
+	case *trackRef:
+		return s.fullTrack(it), "track"
+	case *episodeRef:
+		o := s.simpleEpisode(it)
+		o["show"] = s.simpleShow(it.show)
+		return o, "episode"
+	case *chapterRef:
+		o := s.simpleChapter(it)
+		o["audiobook"] = s.simpleAudiobook(it.book)
+		return o, "episode"
+	}
+	return nil, "unknown"
+}
+
+func (s *Server) deviceJSON(d *device) map[string]any {
+	return map[string]any{
+		"id":                 d.id,
+		"is_active":          s.player.active == d,
+		"is_private_session": false,
+		"is_restricted":      false,
+		"name":               d.name,
+		"type":               d.kind,
+		"volume_percent":     d.volume,
+		"supports_volume":    true,
+	}
+}
+
+// playerHandler wraps a player endpoint with the checks every one of them
+// shares: a user token, a valid device_id if one is given, and an active
+// device unless the endpoint can do without one.
+func (s *Server) playerHandler(needsActive bool, h func(w http.ResponseWriter, r *http.Request, target *device)) func(w http.ResponseWriter, r *http.Request, user bool) {
+	return func(w http.ResponseWriter, r *http.Request, user bool) {
+		if !user {
+			writeError(w, http.StatusUnauthorized, "Valid user authentication required")
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		target := s.player.active
+		if id := r.URL.Query().Get("device_id"); id != "" {
+			if target = s.player.findDevice(id); target == nil {
+				writeError(w, http.StatusNotFound, "Device not found")
+				return
+			}
+		}
+		if needsActive && target == nil {
+			writeError(w, http.StatusNotFound, "Player command failed: No active device found")
+			return
+		}
+		h(w, r, target)
+	}
+}
+
+func (s *Server) handleDevices(w http.ResponseWriter, r *http.Request, _ *device) {
+	devices := []any{}
+	for _, d := range s.player.devices {
+		devices = append(devices, s.deviceJSON(d))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"devices": devices})
+}
+
+func (s *Server) handlePlaybackState(w http.ResponseWriter, r *http.Request, _ *device) {
+	p := &s.player
+	if p.active == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	state := map[string]any{
+		"device":                 s.deviceJSON(p.active),
+		"shuffle_state":          p.shuffle,
+		"repeat_state":           p.repeat,
+		"timestamp":              time.Now().UnixMilli(),
+		"context":                nil,
+		"progress_ms":            p.progress().Milliseconds(),
+		"is_playing":             p.playing,
+		"item":                   nil,
+		"currently_playing_type": "unknown",
+		"actions":                map[string]any{"disallows": map[string]any{}},
+	}
+	if len(p.items) > 0 {
+		state["item"], state["currently_playing_type"] = s.playerItemJSON(p.items[p.index])
+	}
+	if p.context != "" {
+		state["context"] = map[string]any{"type": uriKind(p.context), "uri": p.context}
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request, _ *device) {
+	var body struct {
+		DeviceIDs []string `json:"device_ids"`
+		Play      bool     `json:"play"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.DeviceIDs) != 1 {
+		writeError(w, http.StatusBadRequest, "Exactly one device_id must be given")
+		return
+	}
+	d := s.player.findDevice(body.DeviceIDs[0])
+	if d == nil {
+		writeError(w, http.StatusNotFound, "Device not found")
+		return
+	}
+
+	s.player.active = d
+	if body.Play {
+		s.player.setPlaying(true)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request, target *device) {
+	var body struct {
+		ContextURI string   `json:"context_uri"`
+		URIs       []string `json:"uris"`
+		Offset     *struct {
+			Position *int   `json:"position"`
+			URI      string `json:"uri"`
+		} `json:"offset"`
+		PositionMs int `json:"position_ms"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "Malformed json")
+			return
+		}
+	}
+
+	p := &s.player
+	p.active = target
+
+	if body.ContextURI == "" && len(body.URIs) == 0 {
+		if len(p.items) == 0 {
+			writeError(w, http.StatusNotFound, "Player command failed: Nothing to play")
+			return
+		}
+		p.setPlaying(true)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var items []playerItem
+	if body.ContextURI != "" {
+		resolved, ok := resolveURI(body.ContextURI)
+		if !ok || slices.Contains([]string{"track", "episode", "chapter"}, uriKind(body.ContextURI)) {
+			writeError(w, http.StatusBadRequest, "Invalid context uri")
+			return
+		}
+		items = resolved
+	} else {
+		for _, uri := range body.URIs {
+			resolved, ok := resolveURI(uri)
+			if !ok || len(resolved) != 1 || itemURI(resolved[0]) != uri {
+				writeError(w, http.StatusBadRequest, "Unsupported URL / URI")
+				return
+			}
+			items = append(items, resolved[0])
+		}
+	}
+
+	index := 0
+	if body.Offset != nil {
+		switch {
+		case body.Offset.Position != nil:
+			index = *body.Offset.Position
+		case body.Offset.URI != "":
+			index = slices.IndexFunc(items, func(it playerItem) bool { return itemURI(it) == body.Offset.URI })
+		}
+		if index < 0 || index >= len(items) {
+			writeError(w, http.StatusBadRequest, "Invalid offset")
+			return
+		}
+	}
+
+	p.context = body.ContextURI
+	p.items = items
+	p.index = index
+	p.playing = true
+	p.seek(time.Duration(body.PositionMs) * time.Millisecond)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request, _ *device) {
+	if !s.player.playing {
+		writeError(w, http.StatusForbidden, "Player command failed: Restriction violated")
+		return
+	}
+	s.player.setPlaying(false)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleNext(w http.ResponseWriter, r *http.Request, _ *device) {
+	s.player.skip(1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePrevious(w http.ResponseWriter, r *http.Request, _ *device) {
+	if s.player.progress() > 3*time.Second {
+		s.player.seek(0)
+	} else {
+		s.player.skip(-1)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleSeek(w http.ResponseWriter, r *http.Request, _ *device) {
+	ms, err := strconv.Atoi(r.URL.Query().Get("position_ms"))
+	if err != nil || ms < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid position_ms")
+		return
+	}
+	if len(s.player.items) > 0 {
+		s.player.seek(min(time.Duration(ms)*time.Millisecond, itemDuration(s.player.items[s.player.index])))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleVolume(w http.ResponseWriter, r *http.Request, target *device) {
+	volume, err := strconv.Atoi(r.URL.Query().Get("volume_percent"))
+	if err != nil || volume < 0 || volume > 100 {
+		writeError(w, http.StatusBadRequest, "Invalid volume_percent")
+		return
+	}
+	target.volume = volume
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleShuffle(w http.ResponseWriter, r *http.Request, _ *device) {
+	state, err := strconv.ParseBool(r.URL.Query().Get("state"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid state")
+		return
+	}
+	s.player.shuffle = state
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRepeat(w http.ResponseWriter, r *http.Request, _ *device) {
+	state := r.URL.Query().Get("state")
+	if !slices.Contains([]string{"track", "context", "off"}, state) {
+		writeError(w, http.StatusBadRequest, "Invalid state")
+		return
+	}
+	s.player.repeat = state
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request, _ *device) {
+	p := &s.player
+	queue := []any{}
+	var next []playerItem
+	if len(p.items) > 0 {
+		next = append(slices.Clone(p.queue), p.items[p.index+1:]...)
+	}
+	for _, item := range next[:min(len(next), 20)] {
+		o, _ := s.playerItemJSON(item)
+		queue = append(queue, o)
+	}
+
+	var current any
+	if len(p.items) > 0 {
+		current, _ = s.playerItemJSON(p.items[p.index])
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"currently_playing": current, "queue": queue})
+}
+
+func (s *Server) handleAddToQueue(w http.ResponseWriter, r *http.Request, _ *device) {
+	uri := r.URL.Query().Get("uri")
+	resolved, ok := resolveURI(uri)
+	if !ok || len(resolved) != 1 || itemURI(resolved[0]) != uri {
+		writeError(w, http.StatusBadRequest, "Invalid uri")
+		return
+	}
+	s.player.queue = append(s.player.queue, resolved[0])
+	w.WriteHeader(http.StatusNoContent)
+}
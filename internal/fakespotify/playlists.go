@@ -0,0 +1,327 @@
+package fakespotify
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// UserID is the Spotify user ID of the fake user.
+const UserID = "fakeuser"
+
+// userPlaylist is a playlist the fake user owns and can change. Its snapshot
+// is bumped on every change.
+type userPlaylist struct {
+	id            string
+	name          string
+	description   string
+	public        bool
+	collaborative bool
+	items         []playerItem
+	snapshot      int
+}
+
+func (p *userPlaylist) snapshotID() string {
+	return spotifyID("snapshot", p.id+"/"+strconv.Itoa(p.snapshot))
+}
+
+// defaultPlaylists gives the fake user one playlist of their own to add to.
+func defaultPlaylists() []*userPlaylist {
+	p := &userPlaylist{id: spotifyID("playlist", UserID+"/Road Trip"), name: "Road Trip", public: true}
+	for _, name := range []string{"Everlong", "Go Your Own Way", "Lithium"} {
+		i := slices.IndexFunc(catalogTracks, func(t *trackRef) bool { return t.name == name })
+		p.items = append(p.items, catalogTracks[i])
+	}
+	return []*userPlaylist{p}
+}
+
+func (s *Server) findUserPlaylist(id string) *userPlaylist {
+	for _, p := range s.playlists {
+		if p.id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) userPlaylistJSON(p *userPlaylist) map[string]any {
+	o := s.object("playlist", p.id)
+	o["name"] = p.name
+	o["description"] = p.description
+	o["collaborative"] = p.collaborative
+	o["public"] = p.public
+	o["snapshot_id"] = p.snapshotID()
+	o["images"] = images(p.id)
+	owner := s.object("user", UserID)
+	owner["display_name"] = "Fake User"
+	o["owner"] = owner
+	o["tracks"] = map[string]any{
+		"href":  s.URL + "/v1/playlists/" + p.id + "/tracks",
+		"total": len(p.items),
+	}
+	return o
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	o := s.object("user", UserID)
+	o["display_name"] = "Fake User"
+	o["country"] = "US"
+	o["product"] = "premium"
+	writeJSON(w, http.StatusOK, o)
+}
+
+// handleMyPlaylists lists the fake user's own playlists, newest first.
+func (s *Server) handleMyPlaylists(w http.ResponseWriter, r *http.Request) {
+	var items []any
+	for _, p := range slices.Backward(s.playlists) {
+		items = append(items, s.userPlaylistJSON(p))
+	}
+	s.writePage(w, r, items, 20, 50)
+}
+
+// playlistDetails is the body of a request to create a playlist or change
+// its details. Fields that are left out stay as they are.
+type playlistDetails struct {
+	Name          *string `json:"name"`
+	Description   *string `json:"description"`
+	Public        *bool   `json:"public"`
+	Collaborative *bool   `json:"collaborative"`
+}
+
+func (d playlistDetails) apply(p *userPlaylist) {
+	if d.Name != nil {
+		p.name = *d.Name
+	}
+	if d.Description != nil {
+		p.description = *d.Description
+	}
+	if d.Public != nil {
+		p.public = *d.Public
+	}
+	if d.Collaborative != nil {
+		p.collaborative = *d.Collaborative
+	}
+}
+
+// decodeBody decodes the JSON body of r into v, writing an error if it's
+// not valid.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "Error parsing JSON.")
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleCreatePlaylist(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("user") != UserID {
+		writeError(w, http.StatusForbidden, "You cannot create a playlist for another user")
+		return
+	}
+
+	var details playlistDetails
+	if !decodeBody(w, r, &details) {
+		return
+	}
+	if details.Name == nil || *details.Name == "" {
+		writeError(w, http.StatusBadRequest, "Missing required field: name")
+		return
+	}
+
+	p := &userPlaylist{
+		id:     spotifyID("playlist", UserID+"/"+strconv.Itoa(len(s.playlists))+"/"+*details.Name),
+		public: true,
+	}
+	details.apply(p)
+	s.playlists = append(s.playlists, p)
+	writeJSON(w, http.StatusCreated, s.userPlaylistJSON(p))
+}
+
+// editHandler wraps an endpoint that changes one of the fake user's
+// playlists. Catalog playlists belong to someone else and can't be changed.
+func (s *Server) editHandler(h func(w http.ResponseWriter, r *http.Request, p *userPlaylist)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if p := s.findUserPlaylist(id); p != nil {
+			h(w, r, p)
+			return
+		}
+		if _, ok := findByID(catalogPlaylists, id); ok {
+			writeError(w, http.StatusForbidden, "You cannot change a playlist you don't own")
+			return
+		}
+		writeError(w, http.StatusNotFound, "Resource not found")
+	}
+}
+
+func (s *Server) handleChangePlaylist(w http.ResponseWriter, r *http.Request, p *userPlaylist) {
+	var details playlistDetails
+	if !decodeBody(w, r, &details) {
+		return
+	}
+	if details.Name != nil && *details.Name == "" {
+		writeError(w, http.StatusBadRequest, "Playlist name can't be empty")
+		return
+	}
+	details.apply(p)
+	p.snapshot++
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkSnapshot writes an error unless snapshot is empty or p's latest. Unlike
+// Spotify, the fake doesn't apply changes to older snapshots, so a client that
+// loses track of them finds out.
+func checkSnapshot(w http.ResponseWriter, p *userPlaylist, snapshot string) bool {
+	if snapshot != "" && snapshot != p.snapshotID() {
+		writeError(w, http.StatusBadRequest, "Invalid snapshot id")
+		return false
+	}
+	return true
+}
+
+// playlistItem resolves a track or episode URI to add to a playlist.
+func playlistItem(uri string) (playerItem, bool) {
+	if kind := uriKind(uri); kind != "track" && kind != "episode" {
+		return nil, false
+	}
+	resolved, ok := resolveURI(uri)
+	if !ok {
+		return nil, false
+	}
+	return resolved[0], true
+}
+
+func (s *Server) handleAddToPlaylist(w http.ResponseWriter, r *http.Request, p *userPlaylist) {
+	var body struct {
+		URIs     []string `json:"uris"`
+		Position *int     `json:"position"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if len(body.URIs) == 0 {
+		writeError(w, http.StatusBadRequest, "Missing required field: uris")
+		return
+	}
+	if len(body.URIs) > 100 {
+		writeError(w, http.StatusBadRequest, "You can add a maximum of 100 tracks per request.")
+		return
+	}
+
+	var items []playerItem
+	for _, uri := range body.URIs {
+		item, ok := playlistItem(uri)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "Invalid track uri: "+uri)
+			return
+		}
+		items = append(items, item)
+	}
+
+	position := len(p.items)
+	if body.Position != nil {
+		position = *body.Position
+	}
+	if position < 0 || position > len(p.items) {
+		writeError(w, http.StatusBadRequest, "Index out of bounds")
+		return
+	}
+
+	p.items = slices.Insert(p.items, position, items...)
+	p.snapshot++
+	writeJSON(w, http.StatusCreated, map[string]any{"snapshot_id": p.snapshotID()})
+}
+
+// handleRemoveFromPlaylist removes every occurrence of the given URIs.
+func (s *Server) handleRemoveFromPlaylist(w http.ResponseWriter, r *http.Request, p *userPlaylist) {
+	var body struct {
+		Tracks []struct {
+			URI string `json:"uri"`
+		} `json:"tracks"`
+		SnapshotID string `json:"snapshot_id"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if len(body.Tracks) == 0 {
+		writeError(w, http.StatusBadRequest, "Missing required field: tracks")
+		return
+	}
+	if len(body.Tracks) > 100 {
+		writeError(w, http.StatusBadRequest, "You can remove a maximum of 100 tracks per request.")
+		return
+	}
+	if !checkSnapshot(w, p, body.SnapshotID) {
+		return
+	}
+
+	var uris []string
+	for _, t := range body.Tracks {
+		if _, ok := playlistItem(t.URI); !ok {
+			writeError(w, http.StatusBadRequest, "Invalid track uri: "+t.URI)
+			return
+		}
+		uris = append(uris, t.URI)
+	}
+	p.items = slices.DeleteFunc(p.items, func(item playerItem) bool {
+		return slices.Contains(uris, itemURI(item))
+	})
+	p.snapshot++
+	writeJSON(w, http.StatusOK, map[string]any{"snapshot_id": p.snapshotID()})
+}
+
+// handleReorderPlaylist moves range_length items starting at range_start to
+// before the item at insert_before.
+func (s *Server) handleReorderPlaylist(w http.ResponseWriter, r *http.Request, p *userPlaylist) {
+	var body struct {
+		RangeStart   *int   `json:"range_start"`
+		InsertBefore *int   `json:"insert_before"`
+		RangeLength  *int   `json:"range_length"`
+		SnapshotID   string `json:"snapshot_id"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if body.RangeStart == nil || body.InsertBefore == nil {
+		writeError(w, http.StatusBadRequest, "Missing required field: range_start or insert_before")
+		return
+	}
+	if !checkSnapshot(w, p, body.SnapshotID) {
+		return
+	}
+
+	start, before, length := *body.RangeStart, *body.InsertBefore, 1
+	if body.RangeLength != nil {
+		length = *body.RangeLength
+	}
+	if start < 0 || length < 1 || start+length > len(p.items) || before < 0 || before > len(p.items) {
+		writeError(w, http.StatusBadRequest, "Index out of bounds")
+		return
+	}
+
+	// Inserting before an item of the range itself, or right after it,
+	// leaves everything where it is.
+	if before < start || before > start+length {
+		moved := slices.Clone(p.items[start : start+length])
+		items := slices.Delete(p.items, start, start+length)
+		if before > start {
+			before -= length
+		}
+		p.items = slices.Insert(items, before, moved...)
+	}
+	p.snapshot++
+	writeJSON(w, http.StatusOK, map[string]any{"snapshot_id": p.snapshotID()})
+}
+
+// userPlaylistTracks lists the items of one of the fake user's playlists.
+func (s *Server) userPlaylistTracks(w http.ResponseWriter, r *http.Request, p *userPlaylist) {
+	var items []any
+	added := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	for _, item := range p.items {
+		o, _ := s.playerItemJSON(item)
+		items = append(items, map[string]any{"added_at": added, "is_local": false, "track": o})
+	}
+	s.writePage(w, r, items, 100, 100)
+}
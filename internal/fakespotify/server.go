@@ -0,0 +1,571 @@
+// Package fakespotify is an in-process stand-in for the Spotify Web API and
+// accounts service, backed by a small fixed catalog. It is meant for tests
+// and for running the CLI without network access or credentials.
+package fakespotify
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ClientID     = "fake-client-id"
+	ClientSecret = "fake-client-secret"
+)
+
+type accessToken struct {
+	expires time.Time
+	user    bool
+}
+
+type injected struct {
+	status     int
+	retryAfter time.Duration
+	message    string
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	tokens        map[string]accessToken
+	refreshTokens map[string]bool
+	injected      []injected
+	apiCalls      int
+	player        player
+	library       library
+	playlists     []*userPlaylist
+}
+
+func New() *Server {
+	s := &Server{
+		tokens:        map[string]accessToken{},
+		refreshTokens: map[string]bool{},
+		player:        player{devices: defaultDevices(), repeat: "off"},
+		library:       defaultLibrary(),
+		playlists:     defaultPlaylists(),
+	}
+	s.Server = httptest.NewServer(s.routes())
+	return s
+}
+
+// APIURL is the base URL to use in place of https://api.spotify.com/v1.
+func (s *Server) APIURL() string {
+	return s.URL + "/v1"
+}
+
+// AccountsURL is the base URL to use in place of https://accounts.spotify.com.
+func (s *Server) AccountsURL() string {
+	return s.URL
+}
+
+// Login returns a refresh token for the fake user, as if they had approved
+// access in the browser, so clients can skip the authorization flow.
+func (s *Server) Login() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token := randomToken()
+	s.refreshTokens[token] = true
+	return token
+}
+
+// RateLimit makes the next n API requests fail with 429 Too Many Requests
+// and the given Retry-After.
+func (s *Server) RateLimit(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for range n {
+		s.injected = append(s.injected, injected{status: http.StatusTooManyRequests, retryAfter: retryAfter, message: "API rate limit exceeded"})
+	}
+}
+
+// Fail makes the next API request fail with status and message.
+func (s *Server) Fail(status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.injected = append(s.injected, injected{status: status, message: message})
+}
+
+// APICalls reports how many /v1 requests the server has received.
+func (s *Server) APICalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.apiCalls
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/token", s.handleToken)
+	mux.HandleFunc("GET /authorize", s.handleAuthorize)
+
+	api := func(pattern string, h func(w http.ResponseWriter, r *http.Request, user bool)) {
+		mux.HandleFunc(pattern, s.api(h))
+	}
+	api("GET /v1/search", s.handleSearch)
+	api("GET /v1/albums/{id}", s.handleAlbum)
+	api("GET /v1/artists/{id}", s.handleArtist)
+	api("GET /v1/artists/{id}/top-tracks", s.handleArtistTopTracks)
+	api("GET /v1/artists/{id}/albums", s.handleArtistAlbums)
+	api("GET /v1/tracks/{id}", s.handleTrack)
+	api("GET /v1/playlists/{id}", s.handlePlaylist)
+	api("GET /v1/playlists/{id}/tracks", s.handlePlaylistTracks)
+	api("GET /v1/shows/{id}/episodes", s.handleShowEpisodes)
+	api("GET /v1/audiobooks/{id}/chapters", s.handleAudiobookChapters)
+	api("GET /v1/markets", s.handleMarkets)
+	api("GET /v1/me/player", s.playerHandler(false, s.handlePlaybackState))
+	api("PUT /v1/me/player", s.playerHandler(false, s.handleTransfer))
+	api("GET /v1/me/player/devices", s.playerHandler(false, s.handleDevices))
+	api("PUT /v1/me/player/play", s.playerHandler(true, s.handlePlay))
+	api("PUT /v1/me/player/pause", s.playerHandler(true, s.handlePause))
+	api("POST /v1/me/player/next", s.playerHandler(true, s.handleNext))
+	api("POST /v1/me/player/previous", s.playerHandler(true, s.handlePrevious))
+	api("PUT /v1/me/player/seek", s.playerHandler(true, s.handleSeek))
+	api("PUT /v1/me/player/volume", s.playerHandler(true, s.handleVolume))
+	api("PUT /v1/me/player/shuffle", s.playerHandler(true, s.handleShuffle))
+	api("PUT /v1/me/player/repeat", s.playerHandler(true, s.handleRepeat))
+	api("GET /v1/me/player/queue", s.playerHandler(false, s.handleQueue))
+	api("POST /v1/me/player/queue", s.playerHandler(true, s.handleAddToQueue))
+	for _, collection := range []string{"tracks", "albums", "shows", "episodes", "audiobooks"} {
+		api("GET /v1/me/"+collection, s.userHandler(s.libraryHandler(collection)))
+		api("PUT /v1/me/"+collection, s.userHandler(s.saveHandler(collection, false)))
+		api("DELETE /v1/me/"+collection, s.userHandler(s.saveHandler(collection, true)))
+		api("GET /v1/me/"+collection+"/contains", s.userHandler(s.containsHandler(collection)))
+	}
+	api("GET /v1/me", s.userHandler(s.handleMe))
+	api("GET /v1/me/playlists", s.userHandler(s.handleMyPlaylists))
+	api("POST /v1/users/{user}/playlists", s.userHandler(s.handleCreatePlaylist))
+	api("PUT /v1/playlists/{id}", s.userHandler(s.editHandler(s.handleChangePlaylist)))
+	api("POST /v1/playlists/{id}/tracks", s.userHandler(s.editHandler(s.handleAddToPlaylist)))
+	api("DELETE /v1/playlists/{id}/tracks", s.userHandler(s.editHandler(s.handleRemoveFromPlaylist)))
+	api("PUT /v1/playlists/{id}/tracks", s.userHandler(s.editHandler(s.handleReorderPlaylist)))
+	mux.HandleFunc("/v1/", s.api(func(w http.ResponseWriter, r *http.Request, user bool) {
+		writeError(w, http.StatusNotFound, "Service not found")
+	}))
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{"status": status, "message": message},
+	})
+}
+
+func randomToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// api wraps a /v1 handler with injected failures and bearer token checks.
+func (s *Server) api(h func(w http.ResponseWriter, r *http.Request, user bool)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.apiCalls++
+		var inj *injected
+		if len(s.injected) > 0 {
+			inj = &s.injected[0]
+			s.injected = s.injected[1:]
+		}
+		token, ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		s.mu.Unlock()
+
+		if inj != nil {
+			if inj.retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(inj.retryAfter.Seconds())))
+			}
+			writeError(w, inj.status, inj.message)
+			return
+		}
+
+		switch {
+		case !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "):
+			writeError(w, http.StatusUnauthorized, "No token provided")
+		case !ok:
+			writeError(w, http.StatusUnauthorized, "Invalid access token")
+		case time.Now().After(token.expires):
+			writeError(w, http.StatusUnauthorized, "The access token expired")
+		default:
+			h(w, r, token.user)
+		}
+	}
+}
+
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != ClientID {
+		http.Error(w, "INVALID_CLIENT: Invalid client", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirect.Host == "" {
+		http.Error(w, "INVALID_CLIENT: Invalid redirect URI", http.StatusBadRequest)
+		return
+	}
+
+	code := randomToken()
+	s.mu.Lock()
+	s.refreshTokens["code:"+code] = true
+	s.mu.Unlock()
+
+	rq := redirect.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	redirect.RawQuery = rq.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, hasBasic := r.BasicAuth()
+	if !hasBasic {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != ClientID {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client", "error_description": "Invalid client"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := map[string]any{
+		"token_type": "Bearer",
+		"expires_in": 3600,
+	}
+	access := randomToken()
+
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials":
+		if clientSecret != ClientSecret {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client", "error_description": "Invalid client secret"})
+			return
+		}
+		s.tokens[access] = accessToken{expires: time.Now().Add(time.Hour)}
+	case "authorization_code":
+		code := "code:" + r.PostForm.Get("code")
+		if !s.refreshTokens[code] || r.PostForm.Get("code_verifier") == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "Invalid authorization code"})
+			return
+		}
+		delete(s.refreshTokens, code)
+		refresh := randomToken()
+		s.refreshTokens[refresh] = true
+		s.tokens[access] = accessToken{expires: time.Now().Add(time.Hour), user: true}
+		resp["refresh_token"] = refresh
+		resp["scope"] = r.PostForm.Get("scope")
+	case "refresh_token":
+		if !s.refreshTokens[r.PostForm.Get("refresh_token")] {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "Refresh token revoked"})
+			return
+		}
+		// Spotify may hand out a new refresh token when refreshing. Always do,
+		// revoking the old one, to catch clients that keep using it.
+		delete(s.refreshTokens, r.PostForm.Get("refresh_token"))
+		refresh := randomToken()
+		s.refreshTokens[refresh] = true
+		s.tokens[access] = accessToken{expires: time.Now().Add(time.Hour), user: true}
+		resp["refresh_token"] = refresh
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type", "error_description": "grant_type parameter is missing"})
+		return
+	}
+
+	resp["access_token"] = access
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// paginate slices items according to the request's limit and offset and
+// wraps them in a Spotify paging object.
+func (s *Server) paginate(r *http.Request, items []any, defaultLimit, maxLimit int) (map[string]any, bool) {
+	q := r.URL.Query()
+	limit, offset := defaultLimit, 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLimit {
+			return nil, false
+		}
+		limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		offset = n
+	}
+
+	pageURL := func(offset int) string {
+		u := *r.URL
+		pq := u.Query()
+		pq.Set("offset", strconv.Itoa(offset))
+		pq.Set("limit", strconv.Itoa(limit))
+		u.RawQuery = pq.Encode()
+		return s.URL + u.RequestURI()
+	}
+
+	page := map[string]any{
+		"href":     pageURL(offset),
+		"limit":    limit,
+		"offset":   offset,
+		"total":    len(items),
+		"next":     nil,
+		"previous": nil,
+		"items":    []any{},
+	}
+	if offset < len(items) {
+		page["items"] = items[offset:min(offset+limit, len(items))]
+	}
+	if offset+limit < len(items) {
+		page["next"] = pageURL(offset + limit)
+	}
+	if offset > 0 {
+		page["previous"] = pageURL(max(offset-limit, 0))
+	}
+
+	return page, true
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request, user bool) {
+	q := r.URL.Query()
+	if q.Get("q") == "" {
+		writeError(w, http.StatusBadRequest, "No search query")
+		return
+	}
+	if market := q.Get("market"); market != "" && !validMarket(market) {
+		writeError(w, http.StatusBadRequest, "Invalid market code")
+		return
+	}
+
+	sq := parseQuery(q.Get("q"))
+	resp := map[string]any{}
+	for _, kind := range strings.Split(q.Get("type"), ",") {
+		var items []any
+		switch kind {
+		case "album":
+			for _, a := range catalogAlbums {
+				if sq.matchAlbum(a) {
+					items = append(items, s.simpleAlbum(a))
+				}
+			}
+		case "artist":
+			for _, a := range catalogArtists {
+				if sq.matchArtist(a) {
+					items = append(items, s.fullArtist(a))
+				}
+			}
+		case "playlist":
+			for _, p := range catalogPlaylists {
+				if sq.matchText(p.name, p.description, p.owner) {
+					items = append(items, s.simplePlaylist(p))
+				}
+			}
+		case "track":
+			for _, t := range catalogTracks {
+				if sq.matchTrack(t) {
+					items = append(items, s.fullTrack(t))
+				}
+			}
+		case "show":
+			for _, sh := range catalogShows {
+				if sq.matchText(sh.name, sh.description, sh.publisher) {
+					items = append(items, s.simpleShow(sh))
+				}
+			}
+		case "episode":
+			for _, e := range catalogEpisodes {
+				if sq.matchText(e.name, e.description) {
+					items = append(items, s.simpleEpisode(e))
+				}
+			}
+		case "audiobook":
+			for _, a := range catalogAudiobooks {
+				if sq.matchText(append([]string{a.name}, a.authors...)...) {
+					items = append(items, s.simpleAudiobook(a))
+				}
+			}
+		default:
+			writeError(w, http.StatusBadRequest, "Bad search type field "+kind)
+			return
+		}
+
+		page, ok := s.paginate(r, items, 20, 50)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		resp[kind+"s"] = page
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAlbum(w http.ResponseWriter, r *http.Request, user bool) {
+	a, ok := findByID(catalogAlbums, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.fullAlbum(r, a))
+}
+
+func (s *Server) handleArtist(w http.ResponseWriter, r *http.Request, user bool) {
+	a, ok := findByID(catalogArtists, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.fullArtist(a))
+}
+
+func (s *Server) handleArtistTopTracks(w http.ResponseWriter, r *http.Request, user bool) {
+	a, ok := findByID(catalogArtists, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	tracks := []any{}
+	for _, t := range topTracks(a) {
+		tracks = append(tracks, s.fullTrack(t))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tracks": tracks})
+}
+
+func (s *Server) handleArtistAlbums(w http.ResponseWriter, r *http.Request, user bool) {
+	a, ok := findByID(catalogArtists, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	var items []any
+	for _, al := range catalogAlbums {
+		if al.artist == a {
+			items = append(items, s.simpleAlbum(al))
+		}
+	}
+	s.writePage(w, r, items, 20, 50)
+}
+
+func (s *Server) handleTrack(w http.ResponseWriter, r *http.Request, user bool) {
+	t, ok := findByID(catalogTracks, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.fullTrack(t))
+}
+
+func (s *Server) handlePlaylist(w http.ResponseWriter, r *http.Request, user bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if up := s.findUserPlaylist(r.PathValue("id")); up != nil {
+		writeJSON(w, http.StatusOK, s.userPlaylistJSON(up))
+		return
+	}
+
+	p, ok := findByID(catalogPlaylists, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.simplePlaylist(p))
+}
+
+func (s *Server) handlePlaylistTracks(w http.ResponseWriter, r *http.Request, user bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if up := s.findUserPlaylist(r.PathValue("id")); up != nil {
+		s.userPlaylistTracks(w, r, up)
+		return
+	}
+
+	p, ok := findByID(catalogPlaylists, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	var items []any
+	for _, t := range p.items {
+		items = append(items, map[string]any{
+			"added_at": "2024-01-15T12:00:00Z",
+			"is_local": false,
+			"track":    s.fullTrack(t),
+		})
+	}
+	s.writePage(w, r, items, 100, 100)
+}
+
+func (s *Server) handleShowEpisodes(w http.ResponseWriter, r *http.Request, user bool) {
+	sh, ok := findByID(catalogShows, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	var items []any
+	for _, e := range sh.items {
+		items = append(items, s.simpleEpisode(e))
+	}
+	s.writePage(w, r, items, 20, 50)
+}
+
+func (s *Server) handleAudiobookChapters(w http.ResponseWriter, r *http.Request, user bool) {
+	a, ok := findByID(catalogAudiobooks, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	var items []any
+	for _, c := range a.items {
+		items = append(items, s.simpleChapter(c))
+	}
+	s.writePage(w, r, items, 20, 50)
+}
+
+func (s *Server) handleMarkets(w http.ResponseWriter, r *http.Request, user bool) {
+	writeJSON(w, http.StatusOK, map[string]any{"markets": markets})
+}
+
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, items []any, defaultLimit, maxLimit int) {
+	page, ok := s.paginate(r, items, defaultLimit, maxLimit)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func validMarket(market string) bool {
+	if len(market) != 2 {
+		return false
+	}
+	for _, c := range market {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
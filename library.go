@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+type SimplifiedShow struct {
+	Description   string       `json:"description"`
+	ExternalUrls  ExternalUrls `json:"external_urls"`
+	ID            string       `json:"id"`
+	Name          string       `json:"name"`
+	Publisher     string       `json:"publisher"`
+	TotalEpisodes int          `json:"total_episodes"`
+	Type          string       `json:"type"`
+	URI           string       `json:"uri"`
+}
+
+type Episode struct {
+	SimplifiedEpisode
+	Show SimplifiedShow `json:"show"`
+}
+
+type SimplifiedAudiobook struct {
+	Authors []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	ExternalUrls  ExternalUrls `json:"external_urls"`
+	ID            string       `json:"id"`
+	Name          string       `json:"name"`
+	Publisher     string       `json:"publisher"`
+	TotalChapters int          `json:"total_chapters"`
+	Type          string       `json:"type"`
+	URI           string       `json:"uri"`
+}
+
+type SavedTrack struct {
+	AddedAt string `json:"added_at"`
+	Track   Track  `json:"track"`
+}
+
+type SavedAlbum struct {
+	AddedAt string          `json:"added_at"`
+	Album   SimplifiedAlbum `json:"album"`
+}
+
+type SavedShow struct {
+	AddedAt string         `json:"added_at"`
+	Show    SimplifiedShow `json:"show"`
+}
+
+type SavedEpisode struct {
+	AddedAt string  `json:"added_at"`
+	Episode Episode `json:"episode"`
+}
+
+// me sends a request to one of the /me endpoints, which act on the logged in
+// user's account.
+func (c *Client) me(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	if !c.loggedIn() {
+		return errLoginRequired
+	}
+	return c.do(ctx, method, "/me"+path, query, body, out)
+}
+
+func (c *Client) getSavedTracks(ctx context.Context, market string, offset, limit int) (*Page[SavedTrack], error) {
+	var page Page[SavedTrack]
+	if err := c.me(ctx, http.MethodGet, "/tracks", pageQuery(market, offset, limit), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) getSavedAlbums(ctx context.Context, market string, offset, limit int) (*Page[SavedAlbum], error) {
+	var page Page[SavedAlbum]
+	if err := c.me(ctx, http.MethodGet, "/albums", pageQuery(market, offset, limit), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) getSavedShows(ctx context.Context, offset, limit int) (*Page[SavedShow], error) {
+	var page Page[SavedShow]
+	if err := c.me(ctx, http.MethodGet, "/shows", pageQuery("", offset, limit), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) getSavedEpisodes(ctx context.Context, market string, offset, limit int) (*Page[SavedEpisode], error) {
+	var page Page[SavedEpisode]
+	if err := c.me(ctx, http.MethodGet, "/episodes", pageQuery(market, offset, limit), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// getSavedAudiobooks lists audiobooks as they are, without the added_at of
+// the other saved items.
+func (c *Client) getSavedAudiobooks(ctx context.Context, offset, limit int) (*Page[SimplifiedAudiobook], error) {
+	var page Page[SimplifiedAudiobook]
+	if err := c.me(ctx, http.MethodGet, "/audiobooks", pageQuery("", offset, limit), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// libraryCollection returns the /me collection items of category are saved
+// in, or "" when they can't be saved.
+func libraryCollection(category string) string {
+	switch category {
+	case "Track", "Album", "Show", "Episode", "Audiobook":
+		return strings.ToLower(category) + "s"
+	}
+	return ""
+}
+
+// libraryBatchSize is how many IDs one request to collection can take.
+func libraryBatchSize(collection string) int {
+	if collection == "albums" {
+		return 20
+	}
+	return 50
+}
+
+func (c *Client) saveToLibrary(ctx context.Context, collection string, ids []string) error {
+	for batch := range slices.Chunk(ids, libraryBatchSize(collection)) {
+		q := url.Values{"ids": {strings.Join(batch, ",")}}
+		if err := c.me(ctx, http.MethodPut, "/"+collection, q, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) removeFromLibrary(ctx context.Context, collection string, ids []string) error {
+	for batch := range slices.Chunk(ids, libraryBatchSize(collection)) {
+		q := url.Values{"ids": {strings.Join(batch, ",")}}
+		if err := c.me(ctx, http.MethodDelete, "/"+collection, q, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// librarySaved reports for each of ids whether it's saved in collection.
+func (c *Client) librarySaved(ctx context.Context, collection string, ids []string) ([]bool, error) {
+	var saved []bool
+	for batch := range slices.Chunk(ids, libraryBatchSize(collection)) {
+		var contains []bool
+		q := url.Values{"ids": {strings.Join(batch, ",")}}
+		if err := c.me(ctx, http.MethodGet, "/"+collection+"/contains", q, nil, &contains); err != nil {
+			return nil, err
+		}
+		saved = append(saved, contains...)
+	}
+	return saved, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLibrary(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+	if _, err := client.getSavedTracks(ctx, "", 0, 0); !errors.Is(err, errLoginRequired) {
+		t.Fatalf("got %v, want errLoginRequired", err)
+	}
+
+	client, _ = newLoggedInClient(t)
+
+	var tracks []SavedTrack
+	for offset := 0; ; offset += 20 {
+		page, err := client.getSavedTracks(ctx, "", offset, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tracks = append(tracks, page.Items...)
+		if page.Next == "" {
+			if len(tracks) != page.Total || page.Total <= 20 {
+				t.Errorf("got %d of %d saved tracks, want all of more than a page", len(tracks), page.Total)
+			}
+			break
+		}
+	}
+	if tracks[0].Track.Name != "Smells Like Teen Spirit" || tracks[0].AddedAt <= tracks[1].AddedAt {
+		t.Errorf("got %+v first, want the most recently saved track", tracks[0])
+	}
+
+	albums, err := client.getSavedAlbums(ctx, "", 0, 20)
+	if err != nil || len(albums.Items) != 3 || albums.Items[0].Album.Name != "Nevermind" {
+		t.Errorf("got %+v and %v, want three saved albums", albums, err)
+	}
+	shows, err := client.getSavedShows(ctx, 0, 20)
+	if err != nil || len(shows.Items) != 1 || shows.Items[0].Show.Publisher != "Hrishikesh Hirway" {
+		t.Errorf("got %+v and %v, want Song Exploder", shows, err)
+	}
+	episodes, err := client.getSavedEpisodes(ctx, "", 0, 20)
+	if err != nil || len(episodes.Items) != 2 || episodes.Items[0].Episode.Show.Name != "Song Exploder" {
+		t.Errorf("got %+v and %v, want two Song Exploder episodes", episodes, err)
+	}
+	audiobooks, err := client.getSavedAudiobooks(ctx, 0, 20)
+	if err != nil || len(audiobooks.Items) != 1 || audiobooks.Items[0].Authors[0].Name != "Patti Smith" {
+		t.Errorf("got %+v and %v, want Just Kids", audiobooks, err)
+	}
+}
+
+func TestLibrarySaved(t *testing.T) {
+	client, server := newLoggedInClient(t)
+	ctx := context.Background()
+
+	results, err := client.search(ctx, SearchQuery{Q: "nirvana", Type: "album,track"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	album := results.Albums.Items[0].ID
+
+	if err := client.removeFromLibrary(ctx, "albums", []string{album}); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := client.librarySaved(ctx, "albums", []string{album})
+	if err != nil || saved[0] {
+		t.Fatalf("got %v and %v, want the album removed", saved, err)
+	}
+	if err := client.saveToLibrary(ctx, "albums", []string{album}); err != nil {
+		t.Fatal(err)
+	}
+	page, err := client.getSavedAlbums(ctx, "", 0, 1)
+	if err != nil || page.Items[0].Album.ID != album {
+		t.Fatalf("got %+v and %v, want the album saved again at the top", page, err)
+	}
+
+	// 60 tracks take two requests of 50, and 25 albums two of 20.
+	ids := make([]string, 60)
+	for i := range ids {
+		ids[i] = results.Tracks.Items[i%len(results.Tracks.Items)].ID
+	}
+	before := server.APICalls()
+	saved, err = client.librarySaved(ctx, "tracks", ids)
+	if err != nil || len(saved) != 60 || !saved[0] {
+		t.Fatalf("got %v and %v, want 60 saved tracks", saved, err)
+	}
+	if _, err := client.librarySaved(ctx, "albums", ids[:25]); err != nil {
+		t.Fatal(err)
+	}
+	if calls := server.APICalls() - before; calls != 4 {
+		t.Errorf("got %d API calls, want 4", calls)
+	}
+}
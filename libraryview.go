@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const libraryPageSize = 20
+
+// libraryScrollAhead is how close to the end of the loaded items the cursor
+// gets before the next page is fetched.
+const libraryScrollAhead = 5
+
+var showLibraryKey = key.NewBinding(
+	key.WithKeys("L"),
+	key.WithHelp("L", "your library"),
+)
+
+// librarySection is one of the collections of saved items. load fetches a
+// page of them, reporting whether there are more.
+type librarySection struct {
+	name string
+	load func(ctx context.Context, c *Client, market string, offset int) ([]resultItem, int, bool, error)
+}
+
+var librarySections = []librarySection{
+	{
+		name: "Tracks",
+		load: func(ctx context.Context, c *Client, market string, offset int) ([]resultItem, int, bool, error) {
+			page, err := c.getSavedTracks(ctx, market, offset, libraryPageSize)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			var items []resultItem
+			for _, saved := range page.Items {
+				items = append(items, trackItem(saved.Track.SimplifiedTrack, "Album: "+saved.Track.Album.Name))
+			}
+			return items, page.Total, page.Next != "", nil
+		},
+	},
+	{
+		name: "Albums",
+		load: func(ctx context.Context, c *Client, market string, offset int) ([]resultItem, int, bool, error) {
+			page, err := c.getSavedAlbums(ctx, market, offset, libraryPageSize)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			var items []resultItem
+			for _, saved := range page.Items {
+				items = append(items, albumItem(saved.Album))
+			}
+			return items, page.Total, page.Next != "", nil
+		},
+	},
+	{
+		name: "Shows",
+		load: func(ctx context.Context, c *Client, market string, offset int) ([]resultItem, int, bool, error) {
+			page, err := c.getSavedShows(ctx, offset, libraryPageSize)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			var items []resultItem
+			for _, saved := range page.Items {
+				items = append(items, showItem(saved.Show))
+			}
+			return items, page.Total, page.Next != "", nil
+		},
+	},
+	{
+		name: "Episodes",
+		load: func(ctx context.Context, c *Client, market string, offset int) ([]resultItem, int, bool, error) {
+			page, err := c.getSavedEpisodes(ctx, market, offset, libraryPageSize)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			var items []resultItem
+			for _, saved := range page.Items {
+				e := saved.Episode
+				items = append(items, resultItem{
+					category: "Episode",
+					id:       e.ID,
+					name:     e.Name,
+					detail:   fmt.Sprintf("%s · %s", formatDuration(e.DurationMs), e.Show.Name),
+					url:      e.ExternalUrls.Spotify,
+					uri:      e.URI,
+				})
+			}
+			return items, page.Total, page.Next != "", nil
+		},
+	},
+	{
+		name: "Audiobooks",
+		load: func(ctx context.Context, c *Client, market string, offset int) ([]resultItem, int, bool, error) {
+			page, err := c.getSavedAudiobooks(ctx, offset, libraryPageSize)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			var items []resultItem
+			for _, a := range page.Items {
+				var authors []string
+				for _, au := range a.Authors {
+					authors = append(authors, au.Name)
+				}
+				items = append(items, resultItem{
+					category: "Audiobook",
+					id:       a.ID,
+					name:     a.Name,
+					detail:   fmt.Sprintf("by %s", strings.Join(authors, ", ")),
+					url:      a.ExternalUrls.Spotify,
+					uri:      a.URI,
+				})
+			}
+			return items, page.Total, page.Next != "", nil
+		},
+	},
+}
+
+func showItem(s SimplifiedShow) resultItem {
+	return resultItem{
+		category: "Show",
+		id:       s.ID,
+		name:     s.Name,
+		detail:   fmt.Sprintf("by %s", s.Publisher),
+		url:      s.ExternalUrls.Spotify,
+		uri:      s.URI,
+	}
+}
+
+type libraryMsg struct {
+	seq     int
+	section int
+	offset  int
+	items   []resultItem
+	total   int
+	more    bool
+	err     error
+}
+
+func (m *model) loadLibrary(offset int) tea.Cmd {
+	m.librarySeq = m.startFetch()
+
+	seq, client, market := m.librarySeq, m.client, m.client.Config.Search.Market
+	section := m.librarySection
+	return func() tea.Msg {
+		items, total, more, err := librarySections[section].load(context.Background(), client, market, offset)
+		return libraryMsg{seq: seq, section: section, offset: offset, items: items, total: total, more: more, err: err}
+	}
+}
+
+// libraryLoading reports whether a page of the library is on its way. A page
+// whose fetch was overtaken by another never arrives, so scrolling asks for
+// it again.
+func (m *model) libraryLoading() bool {
+	return m.librarySeq != 0 && m.librarySeq == m.fetchSeq
+}
+
+func (m *model) showLibrary() tea.Cmd {
+	if m.view != LibraryView {
+		m.pushView(LibraryView)
+	}
+	return m.openSection(m.librarySection)
+}
+
+// openSection starts over with the first page of section.
+func (m *model) openSection(section int) tea.Cmd {
+	m.librarySection = section
+	m.libraryMore = false
+	m.libraryList.ResetFilter()
+	m.libraryList.Title = "Saved " + strings.ToLower(librarySections[section].name)
+	return tea.Batch(m.libraryList.SetItems(nil), m.libraryList.StartSpinner(), m.loadLibrary(0))
+}
+
+func (m *model) updateLibraryList(msg libraryMsg) tea.Cmd {
+	if msg.seq != m.fetchSeq {
+		return nil
+	}
+	m.librarySeq = 0
+	m.libraryList.StopSpinner()
+	if msg.err != nil {
+		return m.libraryList.NewStatusMessage(describeError(msg.err))
+	}
+
+	items := m.libraryList.Items()
+	if msg.offset == 0 {
+		items = nil
+	}
+	for _, item := range msg.items {
+		m.saved[item.uri] = true
+		item.saved = true
+		items = append(items, item)
+	}
+	m.libraryMore = msg.more
+	m.libraryList.Title = fmt.Sprintf("Saved %s (%d)", strings.ToLower(librarySections[msg.section].name), msg.total)
+	return m.libraryList.SetItems(items)
+}
+
+// scrollLibrary fetches the next page once the cursor gets close to the end
+// of what's loaded, so the list seems to go on until everything is shown.
+func (m *model) scrollLibrary() tea.Cmd {
+	l := &m.libraryList
+	if !m.libraryMore || m.libraryLoading() || l.IsFiltered() || l.Index() < len(l.Items())-libraryScrollAhead {
+		return nil
+	}
+	return tea.Batch(l.StartSpinner(), m.loadLibrary(len(l.Items())))
+}
+
+func (m model) updateLibrary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.libraryList.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, libraryKeys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, libraryKeys.Back):
+			if m.libraryList.FilterState() == list.FilterApplied && msg.String() == "esc" {
+				m.libraryList.ResetFilter()
+				return m, nil
+			}
+			m.popView()
+			return m, nil
+		case key.Matches(msg, libraryKeys.NextSection):
+			return m, m.openSection((m.librarySection + 1) % len(librarySections))
+		case key.Matches(msg, libraryKeys.PrevSection):
+			return m, m.openSection((m.librarySection + len(librarySections) - 1) % len(librarySections))
+		case key.Matches(msg, libraryKeys.Open):
+			return m, m.openDetail()
+		}
+		if cmd, ok := m.itemAction(msg); ok {
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.libraryList, cmd = m.libraryList.Update(msg)
+	return m, tea.Batch(cmd, m.scrollLibrary())
+}
+
+func (m model) libraryView() string {
+	var s strings.Builder
+
+	s.WriteString("\n ")
+	for i, section := range librarySections {
+		s.WriteString(" ")
+		if i == m.librarySection {
+			s.WriteString(focusedTitleStyle.Render(section.name))
+		} else {
+			s.WriteString(section.name)
+		}
+	}
+	s.WriteString("\n")
+	s.WriteString(m.libraryList.View())
+
+	s.WriteString("\n\n")
+	s.WriteString(m.help.View(libraryKeys))
+
+	return s.String()
+}
+
+type libraryKeyMap struct {
+	Open          key.Binding
+	NextSection   key.Binding
+	PrevSection   key.Binding
+	Play          key.Binding
+	Queue         key.Binding
+	Save          key.Binding
+	AddToPlaylist key.Binding
+	Bookmark      key.Binding
+	OpenWeb       key.Binding
+	OpenApp       key.Binding
+	CopyURL       key.Binding
+	CopyURI       key.Binding
+	CopyID        key.Binding
+	Back          key.Binding
+	Quit          key.Binding
+}
+
+func (k libraryKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Quit}
+}
+
+func (k libraryKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Open, k.NextSection, k.PrevSection},
+		{k.Play, k.Queue, k.Save, k.AddToPlaylist, k.Bookmark, k.OpenWeb, k.OpenApp, k.CopyURL, k.CopyURI, k.CopyID},
+		{k.Back, k.Quit},
+	}
+}
+
+var libraryKeys = libraryKeyMap{
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "view details"),
+	),
+	NextSection: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next section"),
+	),
+	PrevSection: key.NewBinding(
+		key.WithKeys("shift+tab"),
+		key.WithHelp("shift+tab", "previous section"),
+	),
+	Play:          playKey,
+	Queue:         queueKey,
+	Save:          saveKey,
+	AddToPlaylist: addToPlaylistKey,
+	Bookmark:      bookmarkKey,
+	OpenWeb:       openWebKey,
+	OpenApp:       openAppKey,
+	CopyURL:       copyURLKey,
+	CopyURI:       copyURIKey,
+	CopyID:        copyIDKey,
+	Back: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc/q", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,14 +20,40 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/chrismeyers/spotify-cli/internal/fakespotify"
 )
 
 type Config struct {
 	API struct {
-		ClientID     string `json:"clientId"`
-		ClientSecret string `json:"clientSecret"`
+		ClientID     string   `json:"clientId"`
+		ClientSecret string   `json:"clientSecret"`
+		RedirectURI  string   `json:"redirectUri"`
+		Scopes       []string `json:"scopes"`
+		BaseURL      string   `json:"baseUrl"`
+		AccountsURL  string   `json:"accountsUrl"`
+		Timeout      string   `json:"timeout"`
+		Proxy        string   `json:"proxy"`
+		MaxAttempts  int      `json:"maxAttempts"`
+		RetryBudget  string   `json:"retryBudget"`
 	} `json:"api"`
-	TokenPath string
+	Search struct {
+		Market          string `json:"market"`
+		Limit           int    `json:"limit"`
+		IncludeExternal bool   `json:"includeExternal"`
+	} `json:"search"`
+	Player struct {
+		Device     string `json:"device"`
+		DeviceName string `json:"deviceName"`
+	} `json:"player"`
+	Export struct {
+		Format string `json:"format"`
+		Dir    string `json:"dir"`
+	} `json:"export"`
+	Opener        string `json:"opener"`
+	Path          string `json:"-"`
+	TokenPath     string
+	HistoryPath   string `json:"-"`
+	BookmarksPath string `json:"-"`
 }
 
 func loadConfig(path string) (*Config, error) {
@@ -41,6 +71,129 @@ func loadConfig(path string) (*Config, error) {
 	return &config, nil
 }
 
+func findConfig() (*Config, error) {
+	possiblePaths := []string{
+		os.Getenv("XDG_CONFIG_HOME") + "/spotify-cli/config.json",
+		os.Getenv("HOME") + "/.config/spotify-cli/config.json",
+		"./config.json",
+	}
+
+	for _, path := range possiblePaths {
+		if _, statErr := os.Stat(path); statErr == nil {
+			config, err := loadConfig(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			config.Path = path
+			config.TokenPath = filepath.Dir(path) + "/token.json"
+			config.HistoryPath = historyPath()
+			config.BookmarksPath = filepath.Dir(path) + "/bookmarks.json"
+			return config, nil
+		}
+	}
+
+	return nil, errors.New("config.json not found in any common location")
+}
+
+// saveSearch writes the search defaults back to the config file.
+func (c Config) saveSearch() error {
+	return c.saveSection("search", c.Search)
+}
+
+// savePlayer writes the default device back to the config file.
+func (c Config) savePlayer() error {
+	return c.saveSection("player", c.Player)
+}
+
+// saveSection replaces one top level key of the config file with value,
+// leaving the rest of it, and the order of its keys, as it was.
+func (c Config) saveSection(key string, value any) error {
+	if c.Path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("%s: not a JSON object", c.Path)
+	}
+	var keys []string
+	values := map[string]json.RawMessage{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.Path, err)
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("%s: %w", c.Path, err)
+		}
+		keys = append(keys, tok.(string))
+		values[tok.(string)] = v
+	}
+	if !slices.Contains(keys, key) {
+		keys = append(keys, key)
+	}
+	if values[key], err = json.Marshal(value); err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		name, _ := json.Marshal(k)
+		fmt.Fprintf(&b, "\n  %s: ", name)
+		if err := json.Indent(&b, values[k], "  ", "  "); err != nil {
+			return err
+		}
+	}
+	b.WriteString("\n}\n")
+
+	return writeFileAtomic(c.Path, b.Bytes())
+}
+
+// writeFileAtomic replaces the file at path with data, readable only by the
+// user. The data is written to a temporary file that is renamed over path, so
+// nobody reading path sees it half written.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// fakeConfig points the client at an in-process fake of the Spotify API,
+// which lets the whole program run offline without credentials. The files
+// it would keep next to config.json go in dir instead.
+func fakeConfig(server *fakespotify.Server, dir string) *Config {
+	var config Config
+	config.API.ClientID = fakespotify.ClientID
+	config.API.ClientSecret = fakespotify.ClientSecret
+	config.API.BaseURL = server.APIURL()
+	config.API.AccountsURL = server.AccountsURL()
+	config.TokenPath = filepath.Join(dir, "token.json")
+	config.HistoryPath = filepath.Join(dir, "history.json")
+	config.BookmarksPath = filepath.Join(dir, "bookmarks.json")
+
+	return &config
+}
+
 var (
 	docStyle          = lipgloss.NewStyle().Margin(1, 2)
 	categoryStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#1DB954")).Bold(true)
@@ -95,42 +248,341 @@ type choice struct {
 
 type resultItem struct {
 	category string
+	id       string
 	name     string
 	detail   string
 	url      string
+	uri      string
+	saved    bool
 }
 
 func (i resultItem) Title() string { return i.name }
 func (i resultItem) Description() string {
-	return fmt.Sprintf("%s · %s", categoryStyle.Render(i.category), i.detail)
+	category := i.category
+	if i.saved {
+		category += " ♥"
+	}
+	return fmt.Sprintf("%s · %s", categoryStyle.Render(category), i.detail)
 }
 func (i resultItem) FilterValue() string { return i.name }
 
+const resultsPageSize = 10
+
+type categoryPage struct {
+	category   string
+	searchType string
+	items      []list.Item
+	total      int
+	next       string
+}
+
+func categoryPages(r *SearchResults) []categoryPage {
+	var pages []categoryPage
+
+	if r.Albums.Href != "" {
+		page := categoryPage{category: "Album", searchType: "album", total: r.Albums.Total, next: r.Albums.Next}
+		for _, a := range r.Albums.Items {
+			artistNames := []string{}
+			for _, ar := range a.Artists {
+				artistNames = append(artistNames, ar.Name)
+			}
+			page.items = append(page.items, resultItem{
+				category: "Album",
+				id:       a.ID,
+				name:     a.Name,
+				detail:   fmt.Sprintf("by %s · Released: %s", strings.Join(artistNames, ", "), a.ReleaseDate),
+				url:      a.ExternalUrls.Spotify,
+				uri:      a.URI,
+			})
+		}
+		pages = append(pages, page)
+	}
+	if r.Artists.Href != "" {
+		page := categoryPage{category: "Artist", searchType: "artist", total: r.Artists.Total, next: r.Artists.Next}
+		for _, a := range r.Artists.Items {
+			page.items = append(page.items, resultItem{
+				category: "Artist",
+				id:       a.ID,
+				name:     a.Name,
+				detail:   fmt.Sprintf("Genres: %s", strings.Join(a.Genres, ", ")),
+				url:      a.ExternalUrls.Spotify,
+				uri:      a.URI,
+			})
+		}
+		pages = append(pages, page)
+	}
+	if r.Playlists.Href != "" {
+		page := categoryPage{category: "Playlist", searchType: "playlist", total: r.Playlists.Total, next: r.Playlists.Next}
+		for _, p := range r.Playlists.Items {
+			page.items = append(page.items, resultItem{
+				category: "Playlist",
+				id:       p.ID,
+				name:     p.Name,
+				detail:   fmt.Sprintf("by %s · %d tracks", p.Owner.DisplayName, p.Tracks.Total),
+				url:      p.ExternalUrls.Spotify,
+				uri:      p.URI,
+			})
+		}
+		pages = append(pages, page)
+	}
+	if r.Tracks.Href != "" {
+		page := categoryPage{category: "Track", searchType: "track", total: r.Tracks.Total, next: r.Tracks.Next}
+		for _, t := range r.Tracks.Items {
+			artistNames := []string{}
+			for _, a := range t.Artists {
+				artistNames = append(artistNames, a.Name)
+			}
+			page.items = append(page.items, resultItem{
+				category: "Track",
+				id:       t.ID,
+				name:     t.Name,
+				detail:   fmt.Sprintf("by %s · Album: %s", strings.Join(artistNames, ", "), t.Album.Name),
+				url:      t.ExternalUrls.Spotify,
+				uri:      t.URI,
+			})
+		}
+		pages = append(pages, page)
+	}
+	if r.Shows.Href != "" {
+		page := categoryPage{category: "Show", searchType: "show", total: r.Shows.Total, next: r.Shows.Next}
+		for _, s := range r.Shows.Items {
+			page.items = append(page.items, resultItem{
+				category: "Show",
+				id:       s.ID,
+				name:     s.Name,
+				detail:   fmt.Sprintf("by %s", s.Publisher),
+				url:      s.ExternalUrls.Spotify,
+				uri:      s.URI,
+			})
+		}
+		pages = append(pages, page)
+	}
+	if r.Episodes.Href != "" {
+		page := categoryPage{category: "Episode", searchType: "episode", total: r.Episodes.Total, next: r.Episodes.Next}
+		for _, e := range r.Episodes.Items {
+			page.items = append(page.items, resultItem{
+				category: "Episode",
+				id:       e.ID,
+				name:     e.Name,
+				detail:   fmt.Sprintf("by %s", e.Name),
+				url:      e.ExternalUrls.Spotify,
+				uri:      e.URI,
+			})
+		}
+		pages = append(pages, page)
+	}
+	if r.Audiobooks.Href != "" {
+		page := categoryPage{category: "Audiobook", searchType: "audiobook", total: r.Audiobooks.Total, next: r.Audiobooks.Next}
+		for _, a := range r.Audiobooks.Items {
+			authorNames := []string{}
+			for _, au := range a.Authors {
+				authorNames = append(authorNames, au.Name)
+			}
+			page.items = append(page.items, resultItem{
+				category: "Audiobook",
+				id:       a.ID,
+				name:     a.Name,
+				detail:   fmt.Sprintf("by %s", strings.Join(authorNames, ", ")),
+				url:      a.ExternalUrls.Spotify,
+				uri:      a.URI,
+			})
+		}
+		pages = append(pages, page)
+	}
+
+	return pages
+}
+
+type searchMsg struct {
+	seq     int
+	preview bool
+	query   SearchQuery
+	results *SearchResults
+	err     error
+}
+
+// debounceMsg fires once typing in live mode has paused. Only the one
+// matching m.debounceSeq starts a preview search.
+type debounceMsg struct {
+	seq int
+}
+
+type retryMsg RetryEvent
+
+func describeRetry(e RetryEvent) string {
+	var apiErr *APIError
+	if errors.As(e.Err, &apiErr) && apiErr.Kind == RateLimitError {
+		return fmt.Sprintf("Rate limited, retrying in %s", e.Wait.Round(time.Second))
+	}
+	return fmt.Sprintf("Spotify unavailable, retrying in %s", e.Wait.Round(time.Second))
+}
+
+func describeError(err error) string {
+	if errors.Is(err, errLoginRequired) {
+		return "Log in with spotify-cli login to use this"
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+
+	switch apiErr.Kind {
+	case AuthError:
+		return fmt.Sprintf("Spotify rejected the credentials (%s), check config.json", apiErr.Message)
+	case RateLimitError:
+		if apiErr.RetryAfter > 0 {
+			return fmt.Sprintf("Rate limited by Spotify, try again in %s", apiErr.RetryAfter)
+		}
+		return "Rate limited by Spotify, try again shortly"
+	case NetworkError:
+		return fmt.Sprintf("Could not reach Spotify: %v", apiErr.Err)
+	case DecodeError:
+		return "Spotify returned an unexpected response"
+	default:
+		return fmt.Sprintf("Spotify returned an error (%d): %s", apiErr.Status, apiErr.Message)
+	}
+}
+
 type ViewState int
 
 const (
 	SearchView ViewState = iota
 	ResultsView
+	DetailView
+	QueueView
+	DevicesView
+	LibraryView
+	PlaylistsView
+	HistoryView
+	BookmarksView
 )
 
+// pushView shows view, remembering the current one to go back to. A view
+// that's already further back is returned to rather than stacked again, so
+// the views in between are dropped and each view has one place to go back
+// to.
+func (m *model) pushView(view ViewState) {
+	if m.view == view {
+		return
+	}
+	m.cancelFetch()
+	if i := slices.Index(m.viewStack, view); i >= 0 {
+		m.viewStack = m.viewStack[:i]
+	} else {
+		m.viewStack = append(m.viewStack, m.view)
+	}
+	m.view = view
+	m.dropDetails()
+}
+
+// popView goes back to the view shown before the current one, skipping the
+// detail view if it has no pages left.
+func (m *model) popView() {
+	m.cancelFetch()
+	m.view = SearchView
+	for len(m.viewStack) > 0 {
+		m.view = m.viewStack[len(m.viewStack)-1]
+		m.viewStack = m.viewStack[:len(m.viewStack)-1]
+		if m.view != DetailView || len(m.details) > 0 {
+			break
+		}
+		m.view = SearchView
+	}
+	m.dropDetails()
+}
+
+// dropDetails forgets the detail pages once the detail view can no longer
+// be gone back to.
+func (m *model) dropDetails() {
+	if m.view != DetailView && !slices.Contains(m.viewStack, DetailView) {
+		m.details = nil
+	}
+}
+
+// startFetch numbers a fetch that shows its result in a view of its own,
+// like details or the queue. Its response is only used if it carries the
+// latest number, so an older fetch can't replace a newer one's result.
+func (m *model) startFetch() int {
+	m.fetchSeq++
+	return m.fetchSeq
+}
+
+// cancelFetch drops the response of the fetch being waited on, for when the
+// user moves to another view before it arrives.
+func (m *model) cancelFetch() {
+	m.fetchSeq++
+	m.activeList().StopSpinner()
+}
+
 type model struct {
-	sub           chan SearchResults
-	client        Client
-	textInput     textinput.Model
-	choices       []choice
-	cursor        int
-	spinner       spinner.Model
-	loading       bool
-	results       *SearchResults
-	resultList    list.Model
-	error         string
-	view          ViewState
-	searchFocused bool
-	help          help.Model
-}
-
-func initialModel(config *Config) model {
-	client := NewClient(*config)
+	sub             chan tea.Msg
+	client          *Client
+	textInput       textinput.Model
+	choices         []choice
+	cursor          int
+	spinner         spinner.Model
+	loading         bool
+	results         *SearchResults
+	query           SearchQuery
+	pages           []categoryPage
+	loadingMore     bool
+	searchSeq       int
+	cancelSearch    context.CancelFunc
+	retryNotice     string
+	live            bool
+	debounceSeq     int
+	previewing      bool
+	preview         *SearchResults
+	previewQuery    SearchQuery
+	previewError    string
+	showFilters     bool
+	markets         []string
+	saved           map[string]bool
+	loadingMkts     bool
+	setting         int
+	settingsChanged bool
+	playback        *PlaybackState
+	playbackAt      time.Time
+	showFooter      bool
+	polling         bool
+	pollEvery       time.Duration
+	nextPoll        time.Time
+	fetchSeq        int
+	details         []detailPage
+	queueList       list.Model
+	queueHeader     string
+	deviceList      list.Model
+	libraryList     list.Model
+	librarySection  int
+	libraryMore     bool
+	librarySeq      int
+	playlistList    list.Model
+	playlistName    textinput.Model
+	playlistTarget  resultItem
+	namingPlaylist  bool
+	history         []historyEntry
+	historyIndex    int
+	historyDraft    string
+	historyList     list.Model
+	bookmarkList    list.Model
+	bookmarkInput   textinput.Model
+	editingBookmark string
+	width           int
+	height          int
+	resultList      list.Model
+	error           string
+	view            ViewState
+	viewStack       []ViewState
+	focus           searchFocus
+	help            help.Model
+}
+
+func initialModel(client *Client) model {
+	sub := make(chan tea.Msg)
+	client.OnRetry = func(e RetryEvent) {
+		sub <- retryMsg(e)
+	}
 
 	ti := textinput.New()
 	ti.Placeholder = getRandomSearchTerm()
@@ -147,11 +599,45 @@ func initialModel(config *Config) model {
 	l.Title = "Search Results"
 	l.DisableQuitKeybindings()
 
+	ql := list.New(items, list.NewDefaultDelegate(), 40, 2)
+	ql.Title = "Queue"
+	ql.DisableQuitKeybindings()
+
+	dl := list.New(items, list.NewDefaultDelegate(), 40, 2)
+	dl.Title = "Devices"
+	dl.SetStatusBarItemName("device", "devices")
+	dl.DisableQuitKeybindings()
+
+	ll := list.New(items, list.NewDefaultDelegate(), 40, 2)
+	ll.DisableQuitKeybindings()
+
+	pl := list.New(items, list.NewDefaultDelegate(), 40, 2)
+	pl.Title = "Your playlists"
+	pl.SetStatusBarItemName("playlist", "playlists")
+	pl.DisableQuitKeybindings()
+
+	hl := list.New(items, list.NewDefaultDelegate(), 40, 2)
+	hl.Title = "Search history"
+	hl.SetStatusBarItemName("search", "searches")
+	hl.DisableQuitKeybindings()
+
+	bl := list.New(items, list.NewDefaultDelegate(), 40, 2)
+	bl.Title = "Bookmarks"
+	bl.SetStatusBarItemName("bookmark", "bookmarks")
+	bl.DisableQuitKeybindings()
+
+	// Searching works without a history, so a broken one is only reported.
+	var historyError string
+	history, err := loadHistory(client.Config.HistoryPath)
+	if err != nil {
+		historyError = fmt.Sprintf("Could not read the search history: %v", err)
+	}
+
 	h := help.New()
 	h.ShowAll = true
 
 	return model{
-		sub:       make(chan SearchResults),
+		sub:       sub,
 		client:    client,
 		textInput: ti,
 		choices: []choice{
@@ -166,18 +652,35 @@ func initialModel(config *Config) model {
 		spinner:       s,
 		loading:       false,
 		resultList:    l,
-		error:         "",
+		saved:         map[string]bool{},
+		queueList:     ql,
+		deviceList:    dl,
+		libraryList:   ll,
+		playlistList:  pl,
+		playlistName:  newPlaylistInput(),
+		history:       history,
+		historyIndex:  len(history),
+		historyList:   hl,
+		bookmarkList:  bl,
+		bookmarkInput: newBookmarkInput(),
+		error:         historyError,
 		view:          SearchView,
-		searchFocused: true,
+		focus:         inputFocus,
 		help:          h,
 	}
 }
 
 type searchKeyMap struct {
-	Accept key.Binding
-	Toggle key.Binding
-	Search key.Binding
-	Quit   key.Binding
+	Accept    key.Binding
+	Recall    key.Binding
+	Toggle    key.Binding
+	Search    key.Binding
+	Live      key.Binding
+	Filters   key.Binding
+	Library   key.Binding
+	History   key.Binding
+	Bookmarks key.Binding
+	Quit      key.Binding
 }
 
 func (k searchKeyMap) ShortHelp() []key.Binding {
@@ -186,8 +689,8 @@ func (k searchKeyMap) ShortHelp() []key.Binding {
 
 func (k searchKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Accept},
-		{k.Toggle, k.Search, k.Quit},
+		{k.Accept, k.Recall, k.Live, k.Filters},
+		{k.Toggle, k.Search, k.Library, k.History, k.Bookmarks, k.Quit},
 	}
 }
 
@@ -196,27 +699,53 @@ var searchKeys = searchKeyMap{
 		key.WithKeys("right", "l"),
 		key.WithHelp("→", "accept placeholder"),
 	),
+	Recall: recallKey,
 	Toggle: key.NewBinding(
 		key.WithKeys("tab"),
-		key.WithHelp("tab", "toggle input focus"),
+		key.WithHelp("tab", "next section"),
 	),
 	Search: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "submit search"),
 	),
+	Live:      liveKey,
+	Filters:   filtersKey,
+	Library:   libraryKey,
+	History:   historyKey,
+	Bookmarks: showBookmarksKey,
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c"),
 		key.WithHelp("ctrl+c", "quit"),
 	),
 }
 
+var (
+	liveKey = key.NewBinding(
+		key.WithKeys("ctrl+l"),
+		key.WithHelp("ctrl+l", "toggle live search"),
+	)
+	filtersKey = key.NewBinding(
+		key.WithKeys("f1"),
+		key.WithHelp("f1", "filter cheat sheet"),
+	)
+	libraryKey = key.NewBinding(
+		key.WithKeys("f2"),
+		key.WithHelp("f2", "your library"),
+	)
+)
+
 type categoryKeyMap struct {
-	Up     key.Binding
-	Down   key.Binding
-	Select key.Binding
-	Toggle key.Binding
-	Search key.Binding
-	Quit   key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	Select    key.Binding
+	Toggle    key.Binding
+	Search    key.Binding
+	Live      key.Binding
+	Filters   key.Binding
+	Library   key.Binding
+	History   key.Binding
+	Bookmarks key.Binding
+	Quit      key.Binding
 }
 
 func (k categoryKeyMap) ShortHelp() []key.Binding {
@@ -225,8 +754,8 @@ func (k categoryKeyMap) ShortHelp() []key.Binding {
 
 func (k categoryKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Select},
-		{k.Toggle, k.Search, k.Quit},
+		{k.Up, k.Down, k.Select, k.Filters},
+		{k.Toggle, k.Search, k.Live, k.Library, k.History, k.Bookmarks, k.Quit},
 	}
 }
 
@@ -245,12 +774,17 @@ var categoryKeys = categoryKeyMap{
 	),
 	Toggle: key.NewBinding(
 		key.WithKeys("tab"),
-		key.WithHelp("tab", "toggle input focus"),
+		key.WithHelp("tab", "next section"),
 	),
 	Search: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "submit search"),
 	),
+	Live:      liveKey,
+	Filters:   filtersKey,
+	Library:   libraryKey,
+	History:   historyKey,
+	Bookmarks: showBookmarksKey,
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c"),
 		key.WithHelp("ctrl+c", "quit"),
@@ -258,8 +792,27 @@ var categoryKeys = categoryKeyMap{
 }
 
 type resultsKeyMap struct {
-	Back key.Binding
-	Quit key.Binding
+	Open          key.Binding
+	NextPage      key.Binding
+	PrevPage      key.Binding
+	LoadMore      key.Binding
+	ShowQueue     key.Binding
+	ShowDevices   key.Binding
+	ShowLibrary   key.Binding
+	ShowBookmarks key.Binding
+	Play          key.Binding
+	Queue         key.Binding
+	Save          key.Binding
+	AddToPlaylist key.Binding
+	Bookmark      key.Binding
+	Export        key.Binding
+	OpenWeb       key.Binding
+	OpenApp       key.Binding
+	CopyURL       key.Binding
+	CopyURI       key.Binding
+	CopyID        key.Binding
+	Back          key.Binding
+	Quit          key.Binding
 }
 
 func (k resultsKeyMap) ShortHelp() []key.Binding {
@@ -268,12 +821,44 @@ func (k resultsKeyMap) ShortHelp() []key.Binding {
 
 func (k resultsKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Back},
-		{k.Quit},
+		{k.Open, k.NextPage, k.PrevPage, k.LoadMore, k.ShowQueue, k.ShowDevices, k.ShowLibrary, k.ShowBookmarks},
+		{k.Play, k.Queue, k.Save, k.AddToPlaylist, k.Bookmark, k.Export, k.OpenWeb, k.OpenApp, k.CopyURL, k.CopyURI, k.CopyID},
+		{k.Back, k.Quit},
 	}
 }
 
 var resultsKeys = resultsKeyMap{
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "view details"),
+	),
+	NextPage: key.NewBinding(
+		key.WithKeys("right", "l", "pgdown", "f", "d"),
+		key.WithHelp("→/l", "next page"),
+	),
+	PrevPage: key.NewBinding(
+		key.WithKeys("left", "h", "pgup", "b", "u"),
+		key.WithHelp("←/h", "previous page"),
+	),
+	LoadMore: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "load more"),
+	),
+	ShowQueue:     showQueueKey,
+	ShowDevices:   showDevicesKey,
+	ShowLibrary:   showLibraryKey,
+	ShowBookmarks: showBookmarksKey,
+	Play:          playKey,
+	Queue:         queueKey,
+	Save:          saveKey,
+	AddToPlaylist: addToPlaylistKey,
+	Bookmark:      bookmarkKey,
+	Export:        exportKey,
+	OpenWeb:       openWebKey,
+	OpenApp:       openAppKey,
+	CopyURL:       copyURLKey,
+	CopyURI:       copyURIKey,
+	CopyID:        copyIDKey,
 	Back: key.NewBinding(
 		key.WithKeys("esc", "q"),
 		key.WithHelp("esc/q", "go back"),
@@ -284,48 +869,316 @@ var resultsKeys = resultsKeyMap{
 	),
 }
 
-func waitForActivity(sub chan SearchResults) tea.Cmd {
+func waitForActivity(sub chan tea.Msg) tea.Cmd {
 	return func() tea.Msg {
-		return SearchResults(<-sub)
+		return <-sub
+	}
+}
+
+// runSearch starts a search in the background, superseding any search that
+// is still running. Its result arrives on m.sub tagged with m.searchSeq, so
+// results of superseded searches can be told apart and dropped. Preview
+// results are shown in the search view instead of the results view.
+func (m *model) runSearch(query SearchQuery, preview bool) {
+	m.stopSearch()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelSearch = cancel
+	m.searchSeq++
+
+	seq, client, sub := m.searchSeq, m.client, m.sub
+	go func() {
+		results, err := client.search(ctx, query)
+		if ctx.Err() != nil {
+			return
+		}
+		sub <- searchMsg{seq: seq, preview: preview, query: query, results: results, err: err}
+	}()
+}
+
+func (m *model) stopSearch() {
+	if m.cancelSearch != nil {
+		m.cancelSearch()
+		m.cancelSearch = nil
 	}
+	m.loading = false
+	m.loadingMore = false
+	m.previewing = false
+	m.retryNotice = ""
+	m.resultList.StopSpinner()
+}
+
+func (m *model) setResultItems() {
+	var items []list.Item
+	shown, total := 0, 0
+	for _, page := range m.pages {
+		for _, item := range page.items {
+			items = append(items, m.markSaved(item))
+		}
+		shown += len(page.items)
+		total += page.total
+	}
+
+	m.resultList.SetItems(items)
+	m.resultList.Title = fmt.Sprintf("Search Results · showing %d of %d", shown, total)
+}
+
+// loadMore requests the next page of every category that still has results
+// left. All categories share one offset, so exhausted ones are dropped from
+// the query instead.
+func (m *model) loadMore() tea.Cmd {
+	if m.loadingMore {
+		return nil
+	}
+
+	var types []string
+	for _, page := range m.pages {
+		if page.next != "" {
+			types = append(types, page.searchType)
+		}
+	}
+	if len(types) == 0 {
+		return m.resultList.NewStatusMessage("No more results")
+	}
+
+	query := m.query
+	query.Type = strings.Join(types, ",")
+	query.Offset += query.Limit
+
+	m.runSearch(query, false)
+	m.loadingMore = true
+	return m.resultList.StartSpinner()
+}
+
+// searchQuery builds the query for the current input, categories and
+// settings.
+func (m model) searchQuery() SearchQuery {
+	var types []string
+	for _, choice := range m.choices {
+		if choice.selected {
+			types = append(types, choice.searchType)
+		}
+	}
+
+	settings := m.client.Config.Search
+	query := SearchQuery{
+		Q:      m.textInput.Value(),
+		Type:   strings.Join(types, ","),
+		Market: settings.Market,
+		Limit:  searchLimit(settings.Limit),
+	}
+	if settings.IncludeExternal {
+		query.IncludeExternal = "audio"
+	}
+	return query
+}
+
+const liveSearchDelay = 300 * time.Millisecond
+
+// schedulePreview restarts the live search debounce, dropping the preview
+// search in flight since it's for an outdated query.
+func (m *model) schedulePreview() tea.Cmd {
+	if m.previewing {
+		m.stopSearch()
+	}
+	m.debounceSeq++
+	seq := m.debounceSeq
+	return tea.Tick(liveSearchDelay, func(time.Time) tea.Msg {
+		return debounceMsg{seq: seq}
+	})
+}
+
+// previewIfChanged schedules a live preview when a key press changed the
+// query.
+func (m *model) previewIfChanged(before SearchQuery) tea.Cmd {
+	if !m.live || m.view != SearchView || m.searchQuery() == before {
+		return nil
+	}
+	return m.schedulePreview()
+}
+
+func (m *model) clearPreview() {
+	if m.previewing {
+		m.stopSearch()
+	}
+	m.debounceSeq++
+	m.preview = nil
+	m.previewError = ""
+}
+
+// submitSearch runs query for the results view, or shows the live preview
+// if it's for the same query, and adds it to the history.
+func (m *model) submitSearch(query SearchQuery) tea.Cmd {
+	m.error = ""
+	m.recordSearch(query)
+	if m.live && m.preview != nil && m.previewQuery == query {
+		m.showResults(query, m.preview)
+		return m.checkSaved(m.resultList.Items())
+	}
+
+	m.results = nil
+	m.runSearch(query, false)
+	m.loading = true
+	return m.spinner.Tick
+}
+
+func (m *model) showResults(query SearchQuery, results *SearchResults) {
+	m.results = results
+	m.query = query
+	m.pages = categoryPages(results)
+	m.setResultItems()
+	m.resultList.Select(0)
+	m.cancelFetch()
+	m.view = ResultsView
+	m.viewStack = nil
+	m.dropDetails()
 }
 
 func (m model) Init() tea.Cmd {
-	return waitForActivity(m.sub)
+	return tea.Batch(waitForActivity(m.sub), footerTick())
+}
+
+// resize fits the lists between the help and the now playing footer.
+func (m *model) resize() {
+	m.resultList.SetSize(m.width, m.height-m.footerHeight())
+	for i := range m.details {
+		m.details[i].list.SetSize(m.width, m.detailListHeight(len(m.details[i].header)))
+	}
+	m.queueList.SetSize(m.width, m.detailListHeight(1))
+	m.deviceList.SetSize(m.width, m.detailListHeight(1))
+	m.libraryList.SetSize(m.width, m.detailListHeight(1))
+	m.playlistList.SetSize(m.width, m.detailListHeight(1))
+	m.historyList.SetSize(m.width, m.detailListHeight(1))
+	m.bookmarkList.SetSize(m.width, m.detailListHeight(1))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	m = next.(model)
+	if m.view != SearchView || m.focus != settingsFocus {
+		if err := m.saveSettings(); err != nil {
+			m.error = fmt.Sprintf("Could not save settings: %v", err)
+		}
+	}
+	return m, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		switch m.view {
+		case DetailView:
+			return m.updateDetail(msg)
+		case QueueView:
+			return m.updateQueue(msg)
+		case DevicesView:
+			return m.updateDevices(msg)
+		case LibraryView:
+			return m.updateLibrary(msg)
+		case PlaylistsView:
+			return m.updatePlaylists(msg)
+		case HistoryView:
+			return m.updateHistory(msg)
+		case BookmarksView:
+			return m.updateBookmarks(msg)
+		}
+		before := m.searchQuery()
+
+		if m.view == SearchView && m.focus == settingsFocus {
+			if cmd, ok := m.updateSettings(msg); ok {
+				return m, tea.Batch(cmd, m.previewIfChanged(before))
+			}
+		}
+
+		if m.view == ResultsView && m.resultList.FilterState() != list.Filtering {
+			switch {
+			case key.Matches(msg, resultsKeys.Open):
+				return m, m.openDetail()
+			case key.Matches(msg, resultsKeys.ShowQueue):
+				return m, m.showQueue()
+			case key.Matches(msg, resultsKeys.ShowDevices):
+				return m, m.showDevices()
+			case key.Matches(msg, resultsKeys.ShowLibrary):
+				return m, m.showLibrary()
+			case key.Matches(msg, resultsKeys.ShowBookmarks):
+				return m, m.showBookmarks()
+			case key.Matches(msg, resultsKeys.Export):
+				if item, ok := m.resultList.SelectedItem().(resultItem); ok {
+					return m, m.exportPlaylist(item)
+				}
+			}
+			if cmd, ok := m.itemAction(msg); ok {
+				return m, cmd
+			}
+			switch {
+			case m.resultList.IsFiltered():
+			case key.Matches(msg, resultsKeys.LoadMore):
+				return m, m.loadMore()
+			case key.Matches(msg, resultsKeys.NextPage) && m.resultList.Paginator.OnLastPage():
+				return m, m.loadMore()
+			}
+		}
+
 		switch msg.String() {
 		case "ctrl+c":
 			return m, tea.Quit
+		case "ctrl+l":
+			if m.view == SearchView {
+				m.live = !m.live
+				if !m.live {
+					m.clearPreview()
+					return m, nil
+				}
+				return m, m.schedulePreview()
+			}
+		case "f1":
+			if m.view == SearchView {
+				m.showFilters = !m.showFilters
+			}
+		case "f2":
+			if m.view == SearchView {
+				return m, m.showLibrary()
+			}
+		case "f3":
+			if m.view == SearchView {
+				return m, m.showHistory()
+			}
+		case "f4":
+			if m.view == SearchView {
+				return m, m.showBookmarks()
+			}
 		case "tab":
 			if m.view == SearchView {
-				if m.searchFocused {
-					m.searchFocused = false
-					m.textInput.Blur()
-				} else {
-					m.searchFocused = true
+				m.focus = (m.focus + 1) % searchFocusCount
+				switch m.focus {
+				case inputFocus:
 					m.textInput.Focus()
+				case categoriesFocus:
+					m.textInput.Blur()
+				case settingsFocus:
+					cmd = m.loadMarkets()
 				}
 			}
 		case "up", "k":
-			if m.view == SearchView && !m.searchFocused && m.cursor > 0 {
+			if m.view == SearchView && m.focus == categoriesFocus && m.cursor > 0 {
 				m.cursor--
+			} else if m.view == SearchView && m.focus == inputFocus && msg.String() == "up" {
+				m.recallHistory(-1)
 			} else {
 				m.textInput, cmd = m.textInput.Update(msg)
 			}
 		case "down", "j":
-			if m.view == SearchView && !m.searchFocused && m.cursor < len(m.choices)-1 {
+			if m.view == SearchView && m.focus == categoriesFocus && m.cursor < len(m.choices)-1 {
 				m.cursor++
+			} else if m.view == SearchView && m.focus == inputFocus && msg.String() == "down" {
+				m.recallHistory(1)
 			} else {
 				m.textInput, cmd = m.textInput.Update(msg)
 			}
 		case "right":
-			if m.view == SearchView && m.searchFocused {
+			if m.view == SearchView && m.focus == inputFocus {
 				if m.textInput.Value() == "" {
 					m.textInput.SetValue(m.textInput.Placeholder)
 				} else {
@@ -334,7 +1187,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case " ": // space key
 			if m.view == SearchView {
-				if m.searchFocused {
+				if m.focus == inputFocus {
 					m.textInput, cmd = m.textInput.Update(msg)
 				} else {
 					m.choices[m.cursor].selected = !m.choices[m.cursor].selected
@@ -342,163 +1195,178 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "enter":
 			if m.view == SearchView {
-				var types []string
-				for _, choice := range m.choices {
-					if choice.selected {
-						types = append(types, choice.searchType)
-					}
-				}
-
-				input := m.textInput.Value()
-				typeStr := strings.Join(types, ",")
-
-				if input == "" {
+				query := m.searchQuery()
+				if query.Q == "" {
 					m.error = "Please enter a search term"
 					return m, nil
 				}
-				if typeStr == "" {
+				if query.Type == "" {
 					m.error = "Please select at least one category"
 					return m, nil
 				}
-
-				m.error = ""
-				m.results = nil
-				m.loading = !m.loading
-				cmd = m.spinner.Tick
-
-				go func() {
-					results, _ := m.client.search(SearchQuery{Q: input, Type: typeStr})
-					m.sub <- *results
-				}()
+				if err := validateQuery(query.Q, query.Type); err != nil {
+					m.error = err.Error()
+					return m, nil
+				}
+				return m, m.submitSearch(query)
 			}
 		case "esc":
 			if m.view == ResultsView {
 				if m.resultList.FilterState() == list.Filtering {
 					m.resultList.ResetFilter()
 				} else {
-					m.view = SearchView
+					m.stopSearch()
+					m.popView()
 				}
 				return m, nil
 			}
+			if m.view == SearchView && m.loading {
+				m.stopSearch()
+				return m, nil
+			}
 		default:
-			if m.view == SearchView && m.searchFocused {
+			if m.view == SearchView && m.focus == inputFocus {
 				m.textInput, cmd = m.textInput.Update(msg)
 			} else if m.view == ResultsView {
 				if msg.String() == "q" && m.resultList.FilterState() != list.Filtering {
-					m.view = SearchView
+					m.stopSearch()
+					m.popView()
 					return m, nil
 				}
 			}
 		}
-	case SearchResults:
-		m.loading = !m.loading
-		m.results = &msg
 
-		const maxItems = 10
-
-		var items []list.Item
-		for i, a := range msg.Albums.Items {
-			if i >= maxItems {
-				break
-			}
-			artistNames := []string{}
-			for _, ar := range a.Artists {
-				artistNames = append(artistNames, ar.Name)
-			}
-			items = append(items, resultItem{
-				category: "Album",
-				name:     a.Name,
-				detail:   fmt.Sprintf("by %s · Released: %s", strings.Join(artistNames, ", "), a.ReleaseDate),
-				url:      a.ExternalUrls.Spotify,
-			})
+		cmd = tea.Batch(cmd, m.previewIfChanged(before))
+	case debounceMsg:
+		// A preview would cancel a search that was submitted since typing
+		// paused, so it's left to show its results instead.
+		if msg.seq != m.debounceSeq || !m.live || m.view != SearchView || m.loading {
+			return m, nil
 		}
-		for i, a := range msg.Artists.Items {
-			if i >= maxItems {
-				break
-			}
-			items = append(items, resultItem{
-				category: "Artist",
-				name:     a.Name,
-				detail:   fmt.Sprintf("Genres: %s", strings.Join(a.Genres, ", ")),
-				url:      a.ExternalUrls.Spotify,
-			})
+		query := m.searchQuery()
+		if query.Q == "" || query.Type == "" {
+			m.preview = nil
+			m.previewError = ""
+			return m, nil
 		}
-		for i, p := range msg.Playlists.Items {
-			if i >= maxItems {
-				break
-			}
-			items = append(items, resultItem{
-				category: "Playlist",
-				name:     p.Name,
-				detail:   fmt.Sprintf("by %s · %d tracks", p.Owner.DisplayName, p.Tracks.Total),
-				url:      p.ExternalUrls.Spotify,
-			})
+		if m.preview != nil && m.previewQuery == query {
+			return m, nil
 		}
-		for i, t := range msg.Tracks.Items {
-			if i >= maxItems {
-				break
-			}
-			artistNames := []string{}
-			for _, a := range t.Artists {
-				artistNames = append(artistNames, a.Name)
-			}
-			items = append(items, resultItem{
-				category: "Track",
-				name:     t.Name,
-				detail:   fmt.Sprintf("by %s · Album: %s", strings.Join(artistNames, ", "), t.Album.Name),
-				url:      t.ExternalUrls.Spotify,
-			})
+		if err := validateQuery(query.Q, query.Type); err != nil {
+			m.preview = nil
+			m.previewError = err.Error()
+			return m, nil
 		}
-		for i, s := range msg.Shows.Items {
-			if i >= maxItems {
-				break
-			}
-			items = append(items, resultItem{
-				category: "Show",
-				name:     s.Name,
-				detail:   fmt.Sprintf("by %s", s.Publisher),
-				url:      s.ExternalUrls.Spotify,
-			})
+		m.runSearch(query, true)
+		m.previewing = true
+		return m, m.spinner.Tick
+	case marketsMsg:
+		m.loadingMkts = false
+		if msg.err != nil {
+			m.error = describeError(msg.err)
+			return m, nil
 		}
-		for i, e := range msg.Episodes.Items {
-			if i >= maxItems {
-				break
-			}
-			items = append(items, resultItem{
-				category: "Episode",
-				name:     e.Name,
-				detail:   fmt.Sprintf("by %s", e.Name),
-				url:      e.ExternalUrls.Spotify,
-			})
+		m.markets = msg.markets
+		return m, nil
+	case retryMsg:
+		if m.loading || m.previewing {
+			m.retryNotice = describeRetry(RetryEvent(msg))
+			return m, waitForActivity(m.sub)
 		}
-		for i, a := range msg.Audiobooks.Items {
-			if i >= maxItems {
-				break
+		return m, tea.Batch(m.activeList().NewStatusMessage(describeRetry(RetryEvent(msg))), waitForActivity(m.sub))
+	case searchMsg:
+		if msg.seq != m.searchSeq {
+			return m, waitForActivity(m.sub)
+		}
+		m.stopSearch()
+
+		if msg.preview {
+			m.previewQuery = msg.query
+			m.preview = msg.results
+			m.previewError = ""
+			if msg.err != nil {
+				m.preview = nil
+				m.previewError = describeError(msg.err)
 			}
-			items = append(items, resultItem{
-				category: "Audiobook",
-				name:     a.Name,
-				detail:   fmt.Sprintf("by %s", a.Authors[0].Name),
-				url:      a.ExternalUrls.Spotify,
-			})
+			return m, waitForActivity(m.sub)
 		}
 
-		m.resultList.SetItems(items)
-		m.resultList.Select(0)
-		m.view = ResultsView
+		if msg.err != nil {
+			if msg.query.Offset > 0 {
+				cmd = m.resultList.NewStatusMessage(describeError(msg.err))
+				return m, tea.Batch(cmd, waitForActivity(m.sub))
+			}
+			m.error = describeError(msg.err) + " (press enter to retry)"
+			return m, waitForActivity(m.sub)
+		}
+		if msg.query.Offset == 0 {
+			m.showResults(msg.query, msg.results)
+			return m, tea.Batch(waitForActivity(m.sub), m.checkSaved(m.resultList.Items()))
+		}
 
-		return m, waitForActivity(m.sub)
+		m.results = msg.results
+		m.query = msg.query
+		for _, page := range categoryPages(msg.results) {
+			for i := range m.pages {
+				if m.pages[i].category == page.category {
+					m.pages[i].items = append(m.pages[i].items, page.items...)
+					m.pages[i].total = page.total
+					m.pages[i].next = page.next
+				}
+			}
+		}
+		m.setResultItems()
+
+		return m, tea.Batch(waitForActivity(m.sub), m.checkSaved(m.resultList.Items()))
+	case detailMsg:
+		return m, m.pushDetail(msg)
+	case queueMsg:
+		return m, m.updateQueueList(msg)
+	case devicesMsg:
+		return m, m.updateDeviceList(msg)
+	case libraryMsg:
+		return m, m.updateLibraryList(msg)
+	case savedMsg:
+		return m, m.updateSaved(msg)
+	case playlistsMsg:
+		return m, m.updatePlaylistList(msg)
+	case actionMsg:
+		if msg.err != nil {
+			return m, m.activeList().NewStatusMessage(msg.err.Error())
+		}
+		// Whatever the action was, it may have changed what's playing.
+		m.nextPoll = time.Time{}
+		if m.view == DevicesView {
+			cmd = m.fetchDevices()
+		}
+		return m, tea.Batch(cmd, m.activeList().NewStatusMessage(msg.status))
 	case spinner.TickMsg:
-		var cmd tea.Cmd
+		var cmd, listCmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
-		return m, cmd
+		l := m.activeList()
+		*l, listCmd = l.Update(msg)
+		return m, tea.Batch(cmd, listCmd)
 	case tea.WindowSizeMsg:
 		h, v := docStyle.GetFrameSize()
-		m.resultList.SetSize(msg.Width-h, msg.Height-v)
+		m.width, m.height = msg.Width-h, msg.Height-v
+		m.resize()
+	case footerTickMsg:
+		if m.polling || time.Time(msg).Before(m.nextPoll) {
+			return m, footerTick()
+		}
+		m.polling = true
+		return m, tea.Batch(footerTick(), m.fetchPlayback())
+	case playbackMsg:
+		m.updatePlayback(msg)
+		return m, nil
 	}
 
-	if m.view == ResultsView {
+	switch m.view {
+	case ResultsView:
 		m.resultList, cmd = m.resultList.Update(msg)
+	case DetailView, QueueView, DevicesView, LibraryView, PlaylistsView, HistoryView, BookmarksView:
+		l := m.activeList()
+		*l, cmd = l.Update(msg)
 	}
 
 	return m, cmd
@@ -507,25 +1375,37 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) searchView() string {
 	var s strings.Builder
 
-	s.WriteString("Spotify Search\n\n")
+	s.WriteString("Spotify Search")
+	if m.live {
+		s.WriteString(" · live")
+	}
+	s.WriteString("\n\n")
 
 	searchStyle := normalTitleStyle
-	if m.searchFocused {
+	if m.focus == inputFocus {
 		searchStyle = focusedTitleStyle
 	}
 	s.WriteString(searchStyle.Render("Search: "))
 	s.WriteString(m.textInput.View())
-	s.WriteString("\n\n")
+	s.WriteString("\n")
+	if filters := highlightQuery(m.textInput.Value()); filters != "" {
+		indent := strings.Repeat(" ", lipgloss.Width("Search: "))
+		s.WriteString(indent + filters + "\n")
+		if err := validateQuery(m.textInput.Value(), m.searchQuery().Type); err != nil {
+			s.WriteString(indent + "! " + err.Error() + "\n")
+		}
+	}
+	s.WriteString("\n")
 
 	typesStyle := normalTitleStyle
-	if !m.searchFocused {
+	if m.focus == categoriesFocus {
 		typesStyle = focusedTitleStyle
 	}
 	s.WriteString(typesStyle.Render("Categories:"))
 	s.WriteString("\n")
 	for i, choice := range m.choices {
 		cursor := " "
-		if m.cursor == i && !m.searchFocused {
+		if m.cursor == i && m.focus == categoriesFocus {
 			cursor = ">"
 		}
 
@@ -537,19 +1417,87 @@ func (m model) searchView() string {
 		s.WriteString(fmt.Sprintf("%s [%s] %s\n", cursor, checked, choice.name))
 	}
 
+	s.WriteString("\n")
+	s.WriteString(m.settingsView())
+
+	if m.live {
+		s.WriteString("\n")
+		s.WriteString(m.previewView())
+	}
+
 	if m.error != "" {
 		s.WriteString(fmt.Sprintf("\nError: %s\n", m.error))
 	}
 
 	if m.loading {
-		s.WriteString(fmt.Sprintf("\n%s Loading...\n", m.spinner.View()))
+		if m.retryNotice != "" {
+			s.WriteString(fmt.Sprintf("\n%s %s...\n", m.spinner.View(), m.retryNotice))
+		} else {
+			s.WriteString(fmt.Sprintf("\n%s Loading...\n", m.spinner.View()))
+		}
 	}
 
 	s.WriteString("\n\n")
-	if m.searchFocused {
+	switch m.focus {
+	case inputFocus:
 		s.WriteString(m.help.View(searchKeys))
-	} else {
+	case categoriesFocus:
 		s.WriteString(m.help.View(categoryKeys))
+	case settingsFocus:
+		s.WriteString(m.help.View(settingsKeys))
+	}
+	if m.showFilters {
+		s.WriteString("\n\n")
+		s.WriteString(filterHelpView())
+	}
+
+	return s.String()
+}
+
+const previewSize = 5
+
+// previewView renders the first few live search results compactly, one line
+// each, below the categories.
+func (m model) previewView() string {
+	var s strings.Builder
+
+	s.WriteString(normalTitleStyle.Render("Preview:"))
+	if m.previewing {
+		if m.retryNotice != "" {
+			s.WriteString(fmt.Sprintf(" %s %s...", m.spinner.View(), m.retryNotice))
+		} else {
+			s.WriteString(" " + m.spinner.View())
+		}
+	}
+	s.WriteString("\n")
+
+	if m.previewError != "" {
+		s.WriteString(fmt.Sprintf("  Error: %s\n", m.previewError))
+		return s.String()
+	}
+	if m.preview == nil {
+		s.WriteString("  Results appear here as you type\n")
+		return s.String()
+	}
+
+	line := lipgloss.NewStyle().MaxWidth(m.width)
+	shown, total := 0, 0
+	for _, page := range categoryPages(m.preview) {
+		total += page.total
+		for _, item := range page.items {
+			if shown == previewSize {
+				break
+			}
+			i := item.(resultItem)
+			s.WriteString(line.Render(fmt.Sprintf("  %s %s · %s", categoryStyle.Render(fmt.Sprintf("%-9s", i.category)), i.name, i.detail)))
+			s.WriteString("\n")
+			shown++
+		}
+	}
+	if shown == 0 {
+		s.WriteString("  No results\n")
+	} else if total > shown {
+		s.WriteString(fmt.Sprintf("  …%d more, press enter to see them all\n", total-shown))
 	}
 
 	return s.String()
@@ -568,49 +1516,107 @@ func (m model) resultsView() string {
 }
 
 func (m model) View() string {
-	if m.view == ResultsView {
-		return m.resultsView()
+	var view string
+	switch m.view {
+	case ResultsView:
+		view = m.resultsView()
+	case DetailView:
+		view = m.detailView()
+	case QueueView:
+		view = m.queueView()
+	case DevicesView:
+		view = m.devicesView()
+	case LibraryView:
+		view = m.libraryView()
+	case PlaylistsView:
+		view = m.playlistsView()
+	case HistoryView:
+		view = m.historyView()
+	case BookmarksView:
+		view = m.bookmarksView()
+	default:
+		view = m.searchView()
 	}
-	return m.searchView()
+	return view + m.footerView()
 }
 
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs spotify-cli with args and returns its exit code. Exiting is left
+// to main so that everything deferred here, like stopping the fake API, is
+// done first.
+func run(args []string) int {
 	if len(os.Getenv("DEBUG")) > 0 {
 		f, err := tea.LogToFile("debug.log", "debug")
 		if err != nil {
-			panic(err)
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
 		}
 		defer f.Close()
 	}
 
-	possiblePaths := []string{
-		os.Getenv("XDG_CONFIG_HOME") + "/spotify-cli/config.json",
-		os.Getenv("HOME") + "/.config/spotify-cli/config.json",
-		"./config.json",
+	fake := len(os.Getenv("SPOTIFY_CLI_FAKE")) > 0
+	if len(args) > 0 && args[0] == "--fake" {
+		fake = true
+		args = args[1:]
 	}
 
 	var config *Config
-	var err error
-	for _, path := range possiblePaths {
-		if _, statErr := os.Stat(path); statErr == nil {
-			config, err = loadConfig(path)
-			if err != nil {
-				panic(err)
-			}
-			config.TokenPath = filepath.Dir(path) + "/token.json"
-			break
+	var server *fakespotify.Server
+	if fake {
+		server = fakespotify.New()
+		defer server.Close()
+		dir, err := os.MkdirTemp("", "spotify-cli-fake")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
 		}
+		defer os.RemoveAll(dir)
+		config = fakeConfig(server, dir)
+	} else {
+		var err error
+		config, err = findConfig()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+
+	client, err := NewClient(*config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
 	}
-	if config == nil {
-		panic("config.json not found in any common location")
+	if server != nil {
+		// Start out logged in as the fake user, so that everything that
+		// needs an account works too.
+		if err := client.storeRefreshToken(server.Login()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+
+	if len(args) > 0 {
+		return runCommand(client, args[0], args[1:])
 	}
 
 	p := tea.NewProgram(
-		initialModel(config),
+		initialModel(client),
 		tea.WithAltScreen(),       // Enable full screen mode
 		tea.WithMouseCellMotion(), // Enable mouse support
 	)
-	if _, err := p.Run(); err != nil {
-		panic(err)
+	final, err := p.Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	// Quitting from the settings doesn't leave them, so save them here.
+	if m, ok := final.(model); ok {
+		if err := m.saveSettings(); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not save settings: %v\n", err)
+		}
 	}
+	return exitOK
 }
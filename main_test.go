@@ -0,0 +1,1034 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/chrismeyers/spotify-cli/internal/fakespotify"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestMain(m *testing.M) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	os.Exit(m.Run())
+}
+
+// cmdTimeout bounds how long the harness waits for a command. Anything that
+// takes longer, like spinner ticks and cursor blinks, is dropped so the
+// rendered output stays deterministic.
+const cmdTimeout = 50 * time.Millisecond
+
+type harness struct {
+	t      *testing.T
+	m      model
+	server *fakespotify.Server
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	client, server := newTestClient(t)
+	m := initialModel(client)
+	m.textInput.Placeholder = "Bohemian Rhapsody"
+
+	h := &harness{t: t, m: m, server: server}
+	h.send(tea.WindowSizeMsg{Width: 80, Height: 30})
+	return h
+}
+
+func (h *harness) send(msgs ...tea.Msg) {
+	h.t.Helper()
+	for _, msg := range msgs {
+		next, cmd := h.m.Update(msg)
+		h.m = next.(model)
+		h.run(cmd)
+	}
+}
+
+func (h *harness) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+
+	select {
+	case msg := <-done:
+		switch msg := msg.(type) {
+		case nil:
+		case tea.BatchMsg:
+			for _, c := range msg {
+				h.run(c)
+			}
+		default:
+			h.send(msg)
+		}
+	case <-time.After(cmdTimeout):
+	}
+}
+
+func (h *harness) typeText(s string) {
+	h.t.Helper()
+	for _, r := range s {
+		if r == ' ' {
+			h.send(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+		} else {
+			h.send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+}
+
+func (h *harness) press(keys ...tea.KeyType) {
+	h.t.Helper()
+	for _, k := range keys {
+		h.send(tea.KeyMsg{Type: k})
+	}
+}
+
+// deliver updates the model without running the returned command, for
+// messages whose handlers go back to waiting on m.sub.
+func (h *harness) deliver(msg tea.Msg) {
+	next, _ := h.m.Update(msg)
+	h.m = next.(model)
+}
+
+// awaitSearch delivers messages from the search started by the last key
+// press until its result arrives. Commands returned for them are not run,
+// since they only wait for the next message.
+func (h *harness) awaitSearch() {
+	h.t.Helper()
+	for {
+		select {
+		case msg := <-h.m.sub:
+			h.deliver(msg)
+			if msg, ok := msg.(searchMsg); ok && msg.seq == h.m.searchSeq {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			h.t.Fatal("timed out waiting for search results")
+		}
+	}
+}
+
+func (h *harness) assertGolden(name string) {
+	h.t.Helper()
+	assertGolden(h.t, name, h.m.View())
+}
+
+// assertGolden compares got with testdata/<name>.golden, or writes it there
+// with -update.
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("output doesn't match %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+// selectCategories moves to the categories list and toggles the given
+// entries, counted from the top.
+func (h *harness) selectCategories(indexes ...int) {
+	h.t.Helper()
+	h.press(tea.KeyTab)
+	cursor := 0
+	for _, i := range indexes {
+		for ; cursor < i; cursor++ {
+			h.press(tea.KeyDown)
+		}
+		h.typeText(" ")
+	}
+}
+
+func TestSearchViewInitial(t *testing.T) {
+	h := newHarness(t)
+	h.assertGolden("search_initial")
+}
+
+func TestSearchViewFocusToggle(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("nirvana")
+	h.press(tea.KeyTab, tea.KeyDown, tea.KeyDown)
+	h.assertGolden("search_categories_focused")
+
+	h.press(tea.KeyTab, tea.KeyTab)
+	h.assertGolden("search_input_focused")
+}
+
+func TestSearchViewSettings(t *testing.T) {
+	h := newHarness(t)
+	path := filepath.Join(t.TempDir(), "config.json")
+	original := `{"opener": "open", "api": {"clientId": "id"}}`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	h.m.client.Config.Path = path
+
+	h.typeText("nirvana")
+	h.press(tea.KeyTab, tea.KeyTab)
+	h.deliver(marketsMsg{markets: []string{"GB", "SE", "US"}})
+	h.press(tea.KeyRight, tea.KeyRight, tea.KeyDown, tea.KeyRight, tea.KeyDown)
+	h.typeText(" ")
+	h.assertGolden("search_settings")
+
+	want := SearchQuery{Q: "nirvana", Market: "SE", Limit: resultsPageSize + 1, IncludeExternal: "audio"}
+	if got := h.m.searchQuery(); got != want {
+		t.Errorf("got query %+v, want %+v", got, want)
+	}
+
+	// The settings are saved once, when they're left.
+	if data, err := os.ReadFile(path); err != nil || string(data) != original {
+		t.Fatalf("got config %q and %v, want it untouched while changing settings", data, err)
+	}
+	h.press(tea.KeyTab)
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.API.ClientID != "id" || config.Search != h.m.client.Config.Search {
+		t.Errorf("got saved config %+v, want the settings added to it", config)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); !(strings.Index(s, `"opener"`) < strings.Index(s, `"api"`) && strings.Index(s, `"api"`) < strings.Index(s, `"search"`)) {
+		t.Errorf("got config\n%s\nwant its keys kept in order", s)
+	}
+}
+
+func TestSearchViewCategorySelection(t *testing.T) {
+	h := newHarness(t)
+	h.selectCategories(0, 3, 4)
+	h.assertGolden("search_categories_selected")
+
+	h.press(tea.KeyUp)
+	h.typeText(" ")
+	h.assertGolden("search_categories_deselected")
+}
+
+func TestSearchViewAcceptPlaceholder(t *testing.T) {
+	h := newHarness(t)
+	h.press(tea.KeyRight)
+	h.assertGolden("search_placeholder_accepted")
+}
+
+func TestSearchViewValidation(t *testing.T) {
+	h := newHarness(t)
+	h.press(tea.KeyEnter)
+	h.assertGolden("search_missing_term")
+
+	h.typeText("nirvana")
+	h.press(tea.KeyEnter)
+	h.assertGolden("search_missing_category")
+}
+
+func TestSearchViewFilters(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("artist:nirvana year:199")
+	h.selectCategories(0)
+	h.assertGolden("search_filters_invalid")
+
+	h.press(tea.KeyEnter)
+	if h.m.loading || h.m.error != "year: expects a year like 1991 or a range like 1990-1999" {
+		t.Fatalf("got loading %t and error %q, want the filter rejected", h.m.loading, h.m.error)
+	}
+
+	h.press(tea.KeyF1)
+	h.assertGolden("search_filters_help")
+}
+
+func TestSearchViewAPIError(t *testing.T) {
+	client, server := newTestClient(t)
+	h := &harness{t: t, m: initialModel(client)}
+	h.m.textInput.Placeholder = "Bohemian Rhapsody"
+	h.send(tea.WindowSizeMsg{Width: 80, Height: 30})
+
+	for range defaultMaxAttempts {
+		server.Fail(http.StatusServiceUnavailable, "Service unavailable")
+	}
+	h.typeText("nirvana")
+	h.selectCategories(3)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+	h.assertGolden("search_api_error")
+}
+
+func TestSearchViewRateLimited(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("nirvana")
+	h.selectCategories(3)
+	h.press(tea.KeyEnter)
+	h.deliver(retryMsg{Attempt: 1, Wait: 3 * time.Second, Err: &APIError{Kind: RateLimitError, Status: http.StatusTooManyRequests}})
+	h.assertGolden("search_rate_limited")
+	h.awaitSearch()
+}
+
+func TestSearchViewLive(t *testing.T) {
+	h := newHarness(t)
+	h.send(tea.KeyMsg{Type: tea.KeyCtrlL})
+	h.typeText("nirvana")
+	h.selectCategories(3)
+	h.assertGolden("search_live_empty")
+
+	stale := h.m.debounceSeq - 1
+	h.deliver(debounceMsg{seq: stale})
+	if h.m.previewing {
+		t.Fatal("got a preview search for a superseded debounce")
+	}
+
+	h.deliver(debounceMsg{seq: h.m.debounceSeq})
+	h.awaitSearch()
+	if h.m.view != SearchView {
+		t.Fatalf("got view %d, want to stay in the search view", h.m.view)
+	}
+	h.assertGolden("search_live_preview")
+
+	h.press(tea.KeyEnter)
+	if h.m.view != ResultsView || h.m.loading || h.m.cancelSearch != nil {
+		t.Fatalf("got view %d (loading %t), want the previewed results without searching again", h.m.view, h.m.loading)
+	}
+	if len(h.m.resultList.Items()) != 8 {
+		t.Errorf("got %d results, want the 8 previewed tracks", len(h.m.resultList.Items()))
+	}
+}
+
+func TestSearchViewLiveSubmitted(t *testing.T) {
+	h := newHarness(t)
+	h.send(tea.KeyMsg{Type: tea.KeyCtrlL})
+	h.typeText("nirvana")
+	h.selectCategories(3)
+	h.press(tea.KeyEnter)
+	submitted := h.m.searchSeq
+
+	h.deliver(debounceMsg{seq: h.m.debounceSeq})
+	if h.m.previewing || h.m.searchSeq != submitted {
+		t.Fatal("got a preview search cancelling the submitted one")
+	}
+
+	h.awaitSearch()
+	if h.m.view != ResultsView || len(h.m.resultList.Items()) != 8 {
+		t.Errorf("got view %d with %d results, want the submitted search's 8 tracks", h.m.view, len(h.m.resultList.Items()))
+	}
+}
+
+func TestSearchViewLiveToggleOff(t *testing.T) {
+	h := newHarness(t)
+	h.send(tea.KeyMsg{Type: tea.KeyCtrlL})
+	h.typeText("nirvana")
+	h.selectCategories(3)
+	h.deliver(debounceMsg{seq: h.m.debounceSeq})
+	h.awaitSearch()
+
+	h.send(tea.KeyMsg{Type: tea.KeyCtrlL})
+	if h.m.preview != nil {
+		t.Error("got a preview after leaving live mode")
+	}
+	h.assertGolden("search_live_off")
+}
+
+func TestResultsView(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("nirvana")
+	h.selectCategories(0, 3)
+	h.press(tea.KeyEnter)
+	h.assertGolden("search_loading")
+
+	h.awaitSearch()
+	h.assertGolden("results")
+}
+
+func TestResultsViewFilter(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("nirvana")
+	h.selectCategories(3)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+
+	h.typeText("/lith")
+	h.assertGolden("results_filtering")
+
+	h.press(tea.KeyEnter)
+	h.assertGolden("results_filtered")
+
+	h.press(tea.KeyEsc)
+	h.assertGolden("results_filter_cleared")
+}
+
+func TestSearchViewSuperseded(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("nirvana")
+	h.selectCategories(3)
+	h.press(tea.KeyEnter)
+	stale := h.m.searchSeq
+	h.press(tea.KeyEnter)
+	if h.m.searchSeq == stale || !h.m.loading {
+		t.Fatalf("got sequence %d (loading %t), want a new search in flight", h.m.searchSeq, h.m.loading)
+	}
+
+	h.deliver(searchMsg{seq: stale, query: SearchQuery{Q: "nirvana", Type: "track"}, err: errors.New("stale")})
+	if !h.m.loading || h.m.error != "" {
+		t.Fatalf("got loading %t and error %q, want the stale result dropped", h.m.loading, h.m.error)
+	}
+
+	h.awaitSearch()
+	if h.m.loading || h.m.view != ResultsView {
+		t.Fatalf("got loading %t and view %d, want the results view", h.m.loading, h.m.view)
+	}
+}
+
+func TestSearchViewCancel(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("nirvana")
+	h.selectCategories(3)
+	h.press(tea.KeyEnter)
+	h.press(tea.KeyEsc)
+	if h.m.loading || h.m.cancelSearch != nil {
+		t.Fatalf("got loading %t, want the search cancelled", h.m.loading)
+	}
+	h.assertGolden("search_cancelled")
+}
+
+func TestResultsViewActions(t *testing.T) {
+	var copied []string
+	systemClipboard := copyToClipboard
+	copyToClipboard = func(text string) error {
+		copied = append(copied, text)
+		return nil
+	}
+	t.Cleanup(func() { copyToClipboard = systemClipboard })
+
+	h := newHarness(t)
+	h.m.client.Config.Opener = "true"
+	h.typeText("smells like")
+	h.selectCategories(3)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+
+	item := h.m.resultList.SelectedItem().(resultItem)
+	h.typeText("yYi")
+	want := []string{item.url, item.uri, item.id}
+	if strings.Join(copied, " ") != strings.Join(want, " ") {
+		t.Errorf("got %q copied, want %q", copied, want)
+	}
+	if !strings.HasPrefix(item.uri, "spotify:track:") {
+		t.Errorf("got URI %q, want a track URI", item.uri)
+	}
+
+	h.typeText("o")
+	h.assertGolden("results_opened")
+}
+
+func TestResultsViewPlay(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("nevermind")
+	h.selectCategories(0)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+
+	h.typeText("p")
+	h.assertGolden("results_play_logged_out")
+
+	client := h.m.client
+	if err := client.storeRefreshToken(h.server.Login()); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.transferPlayback(context.Background(), fakespotify.DeviceID("Phone"), false); err != nil {
+		t.Fatal(err)
+	}
+	h.typeText("p")
+	h.assertGolden("results_play")
+
+	state, err := client.getPlaybackState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.IsPlaying || state.Context == nil || state.Context.URI != h.m.resultList.SelectedItem().(resultItem).uri {
+		t.Errorf("got playback state %+v, want the album playing", state)
+	}
+}
+
+func TestSearchViewNowPlaying(t *testing.T) {
+	h := newHarness(t)
+	h.deliver(h.m.fetchPlayback()())
+	if h.m.showFooter {
+		t.Error("showed the footer while logged out")
+	}
+
+	client := h.m.client
+	if err := client.storeRefreshToken(h.server.Login()); err != nil {
+		t.Fatal(err)
+	}
+	h.deliver(h.m.fetchPlayback()())
+	h.assertGolden("search_nothing_playing")
+
+	ctx := context.Background()
+	results, err := client.search(ctx, SearchQuery{Q: "album:nevermind", Type: "album"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []func() error{
+		func() error { return client.transferPlayback(ctx, fakespotify.DeviceID("Living Room"), false) },
+		func() error { return client.play(ctx, "", playOptions(results.Albums.Items[0].URI, "")) },
+		func() error { return client.seek(ctx, "", time.Minute) },
+		func() error { return client.pause(ctx, "") },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.deliver(h.m.fetchPlayback()())
+	h.assertGolden("search_now_playing")
+
+	if h.m.pollEvery != pollPaused {
+		t.Errorf("got a %s poll interval, want %s while paused", h.m.pollEvery, pollPaused)
+	}
+}
+
+func TestResultsViewQueue(t *testing.T) {
+	h := newHarness(t)
+	client := h.m.client
+	if err := client.storeRefreshToken(h.server.Login()); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := client.transferPlayback(ctx, fakespotify.DeviceID("Phone"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	h.typeText("nevermind")
+	h.selectCategories(0, 3)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+
+	h.typeText("a")
+	if status := h.m.resultList.View(); !strings.Contains(status, "Only tracks and episodes can be queued") {
+		t.Error("queued an album")
+	}
+
+	h.typeText("p")
+	h.press(tea.KeyDown, tea.KeyDown)
+	h.typeText("a")
+	h.typeText("Q")
+	h.assertGolden("results_queue")
+
+	h.typeText("q")
+	if h.m.view != ResultsView {
+		t.Errorf("got view %d after leaving the queue, want the results", h.m.view)
+	}
+}
+
+func TestResultsViewDevices(t *testing.T) {
+	h := newHarness(t)
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"api": {"clientId": "id"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client := h.m.client
+	client.Config.Path = path
+	if err := client.storeRefreshToken(h.server.Login()); err != nil {
+		t.Fatal(err)
+	}
+
+	h.typeText("nevermind")
+	h.selectCategories(0)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+
+	h.typeText("D")
+	h.press(tea.KeyDown, tea.KeyEnter)
+	h.typeText("s")
+	h.assertGolden("results_devices")
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Player.Device != fakespotify.DeviceID("Work Laptop") {
+		t.Errorf("got saved device %q, want the work laptop", config.Player.Device)
+	}
+
+	h.typeText("q")
+	if err := client.transferPlayback(context.Background(), fakespotify.DeviceID("Phone"), false); err != nil {
+		t.Fatal(err)
+	}
+	h.typeText("p")
+	state, err := client.getPlaybackState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.IsPlaying || state.Device.Name != "Work Laptop" {
+		t.Errorf("got playback state %+v, want playing on the default device", state)
+	}
+}
+
+func TestLibraryView(t *testing.T) {
+	h := newHarness(t)
+	h.press(tea.KeyF2)
+	h.assertGolden("library_logged_out")
+
+	h = newHarness(t)
+	if err := h.m.client.storeRefreshToken(h.server.Login()); err != nil {
+		t.Fatal(err)
+	}
+	h.press(tea.KeyF2)
+	h.assertGolden("library_tracks")
+
+	loaded := len(h.m.libraryList.Items())
+	for range loaded - libraryScrollAhead {
+		h.press(tea.KeyDown)
+	}
+	if got := len(h.m.libraryList.Items()); got <= loaded {
+		t.Errorf("got %d tracks after scrolling down, want more than the first %d", got, loaded)
+	}
+
+	h.press(tea.KeyTab)
+	h.assertGolden("library_albums")
+
+	h.press(tea.KeyEnter)
+	if h.m.view != DetailView {
+		t.Fatalf("got view %d, want the album's details", h.m.view)
+	}
+	h.press(tea.KeyEsc)
+	h.press(tea.KeyEsc)
+	if h.m.view != SearchView {
+		t.Errorf("got view %d after going back twice, want the search", h.m.view)
+	}
+}
+
+func TestLibraryViewStale(t *testing.T) {
+	h := newHarness(t)
+	if err := h.m.client.storeRefreshToken(h.server.Login()); err != nil {
+		t.Fatal(err)
+	}
+	h.press(tea.KeyF2)
+	stale := h.m.fetchSeq
+
+	// A page asked for before leaving the library doesn't replace the one
+	// loaded on coming back.
+	h.press(tea.KeyEsc, tea.KeyF2)
+	loaded := len(h.m.libraryList.Items())
+	h.deliver(libraryMsg{seq: stale, section: h.m.librarySection})
+	if got := len(h.m.libraryList.Items()); got != loaded || loaded == 0 {
+		t.Errorf("got %d tracks after a stale page, want the %d loaded", got, loaded)
+	}
+}
+
+// Going back retraces the way a view was reached, even when the same view
+// was opened again on the way.
+func TestDetailView(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("nevermind")
+	h.selectCategories(0)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+
+	h.press(tea.KeyEnter)
+	h.assertGolden("detail_album")
+
+	h.press(tea.KeyDown, tea.KeyEnter)
+	h.assertGolden("detail_track")
+
+	h.press(tea.KeyEsc)
+	if h.m.view != DetailView || len(h.m.details) != 1 {
+		t.Fatalf("got view %d with %d detail pages, want back to the album", h.m.view, len(h.m.details))
+	}
+	h.press(tea.KeyEsc)
+	if h.m.view != ResultsView || h.m.details != nil {
+		t.Errorf("got view %d with %d detail pages, want back to the results", h.m.view, len(h.m.details))
+	}
+}
+
+func TestDetailViewStale(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("nevermind")
+	h.selectCategories(0, 3)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+
+	// open presses enter without running the fetch it starts.
+	open := func() tea.Cmd {
+		next, cmd := h.m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		h.m = next.(model)
+		return cmd
+	}
+
+	// Details that arrive after going back to the search are dropped.
+	fetch := open()
+	h.press(tea.KeyEsc)
+	h.run(fetch)
+	if h.m.view != SearchView || h.m.details != nil {
+		t.Fatalf("got view %d with %d detail pages, want to stay in the search", h.m.view, len(h.m.details))
+	}
+
+	// So are the details of an item opened before the one last opened.
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+	first := open()
+	h.press(tea.KeyDown)
+	second := open()
+	h.run(second)
+	h.run(first)
+	if len(h.m.details) != 1 || h.m.details[0].item.category != "Track" {
+		t.Errorf("got detail pages %+v, want only the track's", h.m.details)
+	}
+}
+
+func TestViewStack(t *testing.T) {
+	h := newHarness(t)
+	if err := h.m.client.storeRefreshToken(h.server.Login()); err != nil {
+		t.Fatal(err)
+	}
+	h.press(tea.KeyF2, tea.KeyTab, tea.KeyEnter)
+	if h.m.view != DetailView {
+		t.Fatalf("got view %d, want the album's details", h.m.view)
+	}
+	h.typeText("Q")
+	if h.m.view != QueueView {
+		t.Fatalf("got view %d, want the queue", h.m.view)
+	}
+	h.press(tea.KeyEsc)
+	if h.m.view != DetailView || len(h.m.details) != 1 {
+		t.Fatalf("got view %d with %d detail pages, want back to the album", h.m.view, len(h.m.details))
+	}
+
+	h.typeText("L")
+	if h.m.view != LibraryView || h.m.details != nil {
+		t.Fatalf("got view %d with %d detail pages, want the library without them", h.m.view, len(h.m.details))
+	}
+	h.press(tea.KeyEsc)
+	if h.m.view != SearchView {
+		t.Errorf("got view %d, want the search", h.m.view)
+	}
+	h.press(tea.KeyEsc, tea.KeyEsc)
+	if h.m.view != SearchView {
+		t.Errorf("got view %d, want to stay in the search", h.m.view)
+	}
+}
+
+func TestResultsViewSave(t *testing.T) {
+	h := newHarness(t)
+	if err := h.m.client.storeRefreshToken(h.server.Login()); err != nil {
+		t.Fatal(err)
+	}
+
+	h.typeText("nevermind")
+	h.selectCategories(0, 3)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+	// awaitSearch leaves the check that comes with the results to us.
+	h.run(h.m.checkSaved(h.m.resultList.Items()))
+	h.assertGolden("results_saved")
+
+	h.typeText("s")
+	h.assertGolden("results_unsaved")
+
+	album := h.m.resultList.SelectedItem().(resultItem)
+	saved, err := h.m.client.librarySaved(context.Background(), "albums", []string{album.id})
+	if err != nil || saved[0] {
+		t.Errorf("got %v and %v, want the album removed from the library", saved, err)
+	}
+
+	h.typeText("s")
+	if !h.m.resultList.SelectedItem().(resultItem).saved {
+		t.Error("album isn't shown as saved again")
+	}
+}
+
+func TestResultsViewAddToPlaylist(t *testing.T) {
+	h := newHarness(t)
+	client := h.m.client
+	if err := client.storeRefreshToken(h.server.Login()); err != nil {
+		t.Fatal(err)
+	}
+
+	h.typeText("nevermind")
+	h.selectCategories(0, 3)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+
+	h.typeText("+")
+	if status := h.m.resultList.View(); !strings.Contains(status, "Only tracks and episodes can be added") {
+		t.Error("picked a playlist for an album")
+	}
+
+	h.press(tea.KeyDown)
+	track := h.m.resultList.SelectedItem().(resultItem)
+	h.typeText("+")
+	h.assertGolden("results_playlists")
+
+	h.press(tea.KeyEnter)
+	if h.m.view != ResultsView {
+		t.Errorf("got view %d after adding, want the results", h.m.view)
+	}
+	if status := h.m.resultList.View(); !strings.Contains(status, "Added Smells Like Teen Spirit to Road") {
+		t.Errorf("got %q, want the track added to Road Trip", status)
+	}
+
+	h.typeText("+")
+	h.typeText("n")
+	h.typeText("Grunge Mix")
+	h.press(tea.KeyEnter)
+	ctx := context.Background()
+	page, err := client.getMyPlaylists(ctx, 0, 50)
+	if err != nil || len(page.Items) != 2 || page.Items[0].Name != "Grunge Mix" || page.Items[0].Public {
+		t.Fatalf("got %+v and %v, want a new private playlist", page, err)
+	}
+	for _, p := range page.Items {
+		tracks, err := client.getPlaylistTracks(ctx, p.ID, "", 0, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last := tracks.Items[len(tracks.Items)-1].Track; last.URI != track.uri {
+			t.Errorf("got %s last in %s, want %s", last.Name, p.Name, track.name)
+		}
+	}
+}
+
+func TestResultsViewExport(t *testing.T) {
+	h := newHarness(t)
+	dir := t.TempDir()
+	h.m.client.Config.Export.Dir = dir
+	h.m.client.Config.Export.Format = "csv"
+
+	h.typeText("grunge")
+	h.selectCategories(2)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+
+	h.typeText("e")
+	if status := h.m.resultList.View(); !strings.Contains(status, "Exported 6 items") {
+		t.Errorf("got %q, want the playlist exported", status)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "Grunge Forever.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 7 {
+		t.Errorf("got %d lines, want a header and 6 tracks", lines)
+	}
+}
+
+func TestResultsViewBack(t *testing.T) {
+	for _, back := range []tea.KeyMsg{{Type: tea.KeyEsc}, {Type: tea.KeyRunes, Runes: []rune{'q'}}} {
+		t.Run(back.String(), func(t *testing.T) {
+			h := newHarness(t)
+			h.typeText("radiohead")
+			h.selectCategories(1)
+			h.press(tea.KeyEnter)
+			h.awaitSearch()
+
+			h.send(back)
+			if h.m.view != SearchView {
+				t.Fatalf("got view %d, want the search view", h.m.view)
+			}
+			h.assertGolden("results_back")
+		})
+	}
+}
+
+func TestResultsViewLoadMore(t *testing.T) {
+	h := newHarness(t)
+	h.typeText("the")
+	h.selectCategories(3)
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+	if !strings.Contains(h.m.resultList.Title, "showing 10 of") {
+		t.Fatalf("got title %q, want the first page", h.m.resultList.Title)
+	}
+
+	h.typeText("m")
+	h.awaitSearch()
+	h.assertGolden("results_load_more")
+}
+
+func TestSearchViewHistory(t *testing.T) {
+	h := newHarness(t)
+	path := filepath.Join(t.TempDir(), "state", "spotify-cli", "history.json")
+	h.m.client.Config.HistoryPath = path
+
+	h.typeText("nirvana")
+	h.selectCategories(0)
+	h.press(tea.KeyTab, tea.KeyTab, tea.KeyEnter)
+	h.awaitSearch()
+	h.press(tea.KeyEsc, tea.KeyCtrlU)
+	h.typeText("radiohead")
+	h.press(tea.KeyEnter)
+	h.awaitSearch()
+	h.press(tea.KeyEsc, tea.KeyCtrlU)
+	h.typeText("que")
+
+	steps := []struct {
+		key  tea.KeyType
+		want string
+	}{
+		{tea.KeyUp, "radiohead"},
+		{tea.KeyUp, "nirvana"},
+		{tea.KeyUp, "nirvana"},
+		{tea.KeyDown, "radiohead"},
+		{tea.KeyDown, "que"},
+		{tea.KeyDown, "que"},
+	}
+	for i, step := range steps {
+		h.press(step.key)
+		if got := h.m.textInput.Value(); got != step.want {
+			t.Errorf("step %d: got %q, want %q", i, got, step.want)
+		}
+	}
+
+	history, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Query != "nirvana" || !slices.Equal(history[0].Types, []string{"album"}) {
+		t.Errorf("got history %+v", history)
+	}
+	if m := initialModel(h.m.client); len(m.history) != 2 {
+		t.Errorf("got %d searches in a new session, want 2", len(m.history))
+	}
+}
+
+func TestHistoryView(t *testing.T) {
+	h := newHarness(t)
+	path := filepath.Join(t.TempDir(), "history.json")
+	history := []historyEntry{
+		{Query: "nirvana", Types: []string{"album"}, SearchedAt: time.Date(2025, 3, 1, 20, 15, 0, 0, time.UTC)},
+		{Query: "queen", Types: []string{"artist", "track"}, Market: "SE", SearchedAt: time.Date(2025, 3, 2, 9, 30, 0, 0, time.UTC)},
+	}
+	if err := saveHistory(path, history); err != nil {
+		t.Fatal(err)
+	}
+	h.m.client.Config.HistoryPath = path
+	h.m = initialModel(h.m.client)
+	h.m.textInput.Placeholder = "Bohemian Rhapsody"
+	h.send(tea.WindowSizeMsg{Width: 80, Height: 30})
+
+	h.press(tea.KeyF3)
+	h.assertGolden("history")
+
+	h.press(tea.KeyDown)
+	h.typeText("x")
+	if got, _ := loadHistory(path); len(got) != 1 || got[0].Query != "queen" {
+		t.Errorf("got history %+v after deleting, want only queen", got)
+	}
+
+	h.press(tea.KeyEnter)
+	if h.m.view != SearchView || !h.m.loading || h.m.textInput.Value() != "queen" || !h.m.choices[1].selected || !h.m.choices[3].selected {
+		t.Errorf("got view %v loading %v with %q, want the queen search running", h.m.view, h.m.loading, h.m.textInput.Value())
+	}
+	h.awaitSearch()
+	if h.m.view != ResultsView || h.m.query.Market != "SE" {
+		t.Errorf("got view %v with market %q, want results for SE", h.m.view, h.m.query.Market)
+	}
+	if got, _ := loadHistory(path); len(got) != 1 || !got[0].SearchedAt.After(history[1].SearchedAt) {
+		t.Errorf("got history %+v, want queen searched again", got)
+	}
+}
+
+func TestBookmarksView(t *testing.T) {
+	h := newHarness(t)
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+	h.m.client.Config.BookmarksPath = path
+
+	h.typeText("nirvana")
+	h.selectCategories(0, 3)
+	h.press(tea.KeyTab, tea.KeyTab, tea.KeyEnter)
+	h.awaitSearch()
+	album := h.m.resultList.SelectedItem().(resultItem)
+	h.typeText("B")
+	h.press(tea.KeyDown)
+	h.typeText("B")
+
+	bookmarks, err := loadBookmarks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := bookmarks[album.uri]; len(bookmarks) != 2 || !ok || b.Name != album.name {
+		t.Fatalf("got bookmarks %+v, want two starting with %s", bookmarks, album.name)
+	}
+
+	h.press(tea.KeyF4)
+	if h.m.view != BookmarksView || len(h.m.bookmarkList.Items()) != 2 {
+		t.Fatalf("got view %v with %d items, want the two bookmarks", h.m.view, len(h.m.bookmarkList.Items()))
+	}
+	h.press(tea.KeyDown)
+	h.typeText("t")
+	h.typeText("campaign, #Q3 ideas, campaign")
+	h.press(tea.KeyEnter)
+	h.typeText("n")
+	h.typeText("Opening shot for the spring ad")
+	h.press(tea.KeyEnter)
+	h.assertGolden("bookmarks")
+
+	bookmarks, _ = loadBookmarks(path)
+	if b := bookmarks[album.uri]; !slices.Equal(b.Tags, []string{"campaign", "Q3-ideas"}) || b.Note != "Opening shot for the spring ad" {
+		t.Errorf("got tags %q and note %q", b.Tags, b.Note)
+	}
+
+	h.typeText("/campaign")
+	h.press(tea.KeyEnter)
+	if items := h.m.bookmarkList.VisibleItems(); len(items) != 1 || items[0].(bookmarkItem).uri != album.uri {
+		t.Errorf("got %d bookmarks for #campaign, want the album", len(items))
+	}
+
+	h.press(tea.KeyEsc)
+	h.typeText("x")
+	if bookmarks, _ = loadBookmarks(path); len(bookmarks) != 1 {
+		t.Errorf("got %d bookmarks after removing one, want 1", len(bookmarks))
+	}
+	h.press(tea.KeyEsc)
+	if h.m.view != ResultsView {
+		t.Errorf("got view %v after going back, want the results", h.m.view)
+	}
+}
+
+func TestRunFakeCleansUp(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	discardOutput(t)
+
+	if got := run([]string{"--fake", "search", "nirvana"}); got != exitOK {
+		t.Errorf("got exit code %d, want %d", got, exitOK)
+	}
+	if got := run([]string{"--fake", "search"}); got != exitUsage {
+		t.Errorf("got exit code %d, want %d", got, exitUsage)
+	}
+
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d files left in the temporary directory, want none", len(entries))
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The footer polls the player less often the less there is going on, and
+// backs off when polling fails.
+const (
+	pollPlaying    = 5 * time.Second
+	pollPaused     = 15 * time.Second
+	pollIdle       = 30 * time.Second
+	pollMaxBackoff = 2 * time.Minute
+)
+
+// footerTickMsg redraws the footer's progress every second, and polls the
+// player when the next poll is due.
+type footerTickMsg time.Time
+
+type playbackMsg struct {
+	state *PlaybackState
+	err   error
+}
+
+func footerTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return footerTickMsg(t)
+	})
+}
+
+// fetchPlayback gets the playback state without the client's retries, since
+// the footer backs off on its own and retry notices would only be noise.
+func (m model) fetchPlayback() tea.Cmd {
+	client := m.client
+	return func() tea.Msg {
+		state, err := client.getPlaybackState(withoutRetries(context.Background()))
+		return playbackMsg{state: state, err: err}
+	}
+}
+
+// pollInterval decides when to poll next after a poll that returned state
+// and err. prev is the previous interval, which is doubled on errors.
+func pollInterval(state *PlaybackState, err error, prev time.Duration) time.Duration {
+	if errors.Is(err, errLoginRequired) {
+		return pollIdle
+	}
+	if err != nil {
+		next := min(max(2*prev, pollPlaying), pollMaxBackoff)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > next {
+			next = apiErr.RetryAfter
+		}
+		return next
+	}
+
+	switch {
+	case state == nil || state.Item == nil:
+		return pollIdle
+	case !state.IsPlaying:
+		return pollPaused
+	}
+	// Poll right after the track ends to pick up the next one.
+	remaining := time.Duration(state.Item.DurationMs-state.ProgressMs) * time.Millisecond
+	return max(min(remaining+500*time.Millisecond, pollPlaying), time.Second)
+}
+
+func (m *model) updatePlayback(msg playbackMsg) {
+	m.polling = false
+	m.pollEvery = pollInterval(msg.state, msg.err, m.pollEvery)
+	m.nextPoll = time.Now().Add(m.pollEvery)
+
+	switch {
+	case errors.Is(msg.err, errLoginRequired):
+		m.playback, m.showFooter = nil, false
+	case msg.err == nil:
+		m.playback, m.playbackAt, m.showFooter = msg.state, time.Now(), true
+	}
+	m.resize()
+}
+
+// progress estimates how far into the item playback is, counting from when
+// the state was fetched.
+func (m model) progress() int {
+	state := m.playback
+	progress := state.ProgressMs
+	if state.IsPlaying {
+		progress += int(time.Since(m.playbackAt).Milliseconds())
+	}
+	return min(progress, state.Item.DurationMs)
+}
+
+func (m model) footerHeight() int {
+	switch {
+	case !m.showFooter:
+		return 0
+	case m.playback == nil || m.playback.Item == nil:
+		return 3
+	}
+	return 4
+}
+
+func (m model) footerView() string {
+	if !m.showFooter {
+		return ""
+	}
+
+	line := lipgloss.NewStyle().MaxWidth(m.width)
+	state := m.playback
+	if state == nil || state.Item == nil {
+		return "\n\n" + line.Render("■ Nothing playing") + "\n"
+	}
+
+	icon := "▶"
+	if !state.IsPlaying {
+		icon = "⏸"
+	}
+	by := artistNames(state.Item.Artists)
+	if state.Item.Show != nil {
+		by = state.Item.Show.Name
+	}
+	device := " on " + state.Device.Name
+
+	title := fmt.Sprintf("%s %s · %s", icon, state.Item.Name, by)
+	title = lipgloss.NewStyle().MaxWidth(max(m.width-lipgloss.Width(device), 0)).Render(title)
+
+	progress, duration := formatDuration(m.progress()), formatDuration(state.Item.DurationMs)
+	barWidth := max(m.width-lipgloss.Width(progress)-lipgloss.Width(duration)-4, 0)
+	filled := 0
+	if state.Item.DurationMs > 0 {
+		filled = barWidth * m.progress() / state.Item.DurationMs
+	}
+	bar := categoryStyle.Render(strings.Repeat("━", filled)) + strings.Repeat("─", barWidth-filled)
+
+	var s strings.Builder
+	s.WriteString("\n\n")
+	s.WriteString(title + normalTitleStyle.Render(device))
+	s.WriteString("\n")
+	s.WriteString(line.Render(fmt.Sprintf("  %s %s %s", progress, bar, duration)))
+	s.WriteString("\n")
+	return s.String()
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errLoginRequired = errors.New("this needs your Spotify account, run spotify-cli login first")
+
+type Device struct {
+	ID               string `json:"id"`
+	IsActive         bool   `json:"is_active"`
+	IsPrivateSession bool   `json:"is_private_session"`
+	IsRestricted     bool   `json:"is_restricted"`
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	VolumePercent    *int   `json:"volume_percent"`
+	SupportsVolume   bool   `json:"supports_volume"`
+}
+
+// PlaybackItem is the track or episode being played. Album and Artists are
+// only set for tracks, and Show only for episodes.
+type PlaybackItem struct {
+	Track
+	Show *struct {
+		Name      string `json:"name"`
+		Publisher string `json:"publisher"`
+	} `json:"show"`
+}
+
+type PlaybackState struct {
+	Device       Device `json:"device"`
+	RepeatState  string `json:"repeat_state"`
+	ShuffleState bool   `json:"shuffle_state"`
+	Context      *struct {
+		Type string `json:"type"`
+		URI  string `json:"uri"`
+	} `json:"context"`
+	Timestamp            int64         `json:"timestamp"`
+	ProgressMs           int           `json:"progress_ms"`
+	IsPlaying            bool          `json:"is_playing"`
+	Item                 *PlaybackItem `json:"item"`
+	CurrentlyPlayingType string        `json:"currently_playing_type"`
+}
+
+// PlayOptions says what to play: either the items of a context, like an
+// album or playlist, starting at Offset, or a list of track and episode URIs.
+type PlayOptions struct {
+	ContextURI string      `json:"context_uri,omitempty"`
+	URIs       []string    `json:"uris,omitempty"`
+	Offset     *PlayOffset `json:"offset,omitempty"`
+	PositionMs int         `json:"position_ms,omitempty"`
+}
+
+type PlayOffset struct {
+	Position *int   `json:"position,omitempty"`
+	URI      string `json:"uri,omitempty"`
+}
+
+// uriKind returns the object type of a spotify: URI, like "album".
+func uriKind(uri string) string {
+	parts := strings.Split(uri, ":")
+	if len(parts) != 3 || parts[0] != "spotify" {
+		return ""
+	}
+	return parts[1]
+}
+
+// playOptions plays a track or episode on its own, or from its position in
+// contextURI when that's an album or playlist. Anything else is played as a
+// context.
+func playOptions(uri, contextURI string) *PlayOptions {
+	switch uriKind(uri) {
+	case "track", "episode", "chapter":
+		if kind := uriKind(contextURI); kind == "album" || kind == "playlist" {
+			return &PlayOptions{ContextURI: contextURI, Offset: &PlayOffset{URI: uri}}
+		}
+		return &PlayOptions{URIs: []string{uri}}
+	}
+	return &PlayOptions{ContextURI: uri}
+}
+
+// player sends a request to one of the /me/player endpoints, which all act
+// on the logged in user's devices.
+func (c *Client) player(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	if !c.loggedIn() {
+		return errLoginRequired
+	}
+	return c.do(ctx, method, "/me/player"+path, query, body, out)
+}
+
+func deviceQuery(deviceID string) url.Values {
+	q := url.Values{}
+	if deviceID != "" {
+		q.Add("device_id", deviceID)
+	}
+	return q
+}
+
+func (c *Client) getDevices(ctx context.Context) ([]Device, error) {
+	var resp struct {
+		Devices []Device `json:"devices"`
+	}
+	if err := c.player(ctx, http.MethodGet, "/devices", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Devices, nil
+}
+
+// getPlaybackState returns nil when no device is active.
+func (c *Client) getPlaybackState(ctx context.Context) (*PlaybackState, error) {
+	var state *PlaybackState
+	if err := c.player(ctx, http.MethodGet, "", url.Values{"additional_types": {"episode"}}, nil, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// play starts playback on the device, or the active one when deviceID is
+// empty. A nil opts resumes what was playing.
+func (c *Client) play(ctx context.Context, deviceID string, opts *PlayOptions) error {
+	var body any
+	if opts != nil {
+		body = opts
+	}
+	return c.player(ctx, http.MethodPut, "/play", deviceQuery(deviceID), body, nil)
+}
+
+func (c *Client) pause(ctx context.Context, deviceID string) error {
+	return c.player(ctx, http.MethodPut, "/pause", deviceQuery(deviceID), nil, nil)
+}
+
+func (c *Client) skipNext(ctx context.Context, deviceID string) error {
+	return c.player(ctx, http.MethodPost, "/next", deviceQuery(deviceID), nil, nil)
+}
+
+func (c *Client) skipPrevious(ctx context.Context, deviceID string) error {
+	return c.player(ctx, http.MethodPost, "/previous", deviceQuery(deviceID), nil, nil)
+}
+
+func (c *Client) seek(ctx context.Context, deviceID string, position time.Duration) error {
+	q := deviceQuery(deviceID)
+	q.Add("position_ms", strconv.FormatInt(position.Milliseconds(), 10))
+	return c.player(ctx, http.MethodPut, "/seek", q, nil, nil)
+}
+
+func (c *Client) setVolume(ctx context.Context, deviceID string, percent int) error {
+	q := deviceQuery(deviceID)
+	q.Add("volume_percent", strconv.Itoa(percent))
+	return c.player(ctx, http.MethodPut, "/volume", q, nil, nil)
+}
+
+func (c *Client) setShuffle(ctx context.Context, deviceID string, on bool) error {
+	q := deviceQuery(deviceID)
+	q.Add("state", strconv.FormatBool(on))
+	return c.player(ctx, http.MethodPut, "/shuffle", q, nil, nil)
+}
+
+// setRepeat sets the repeat mode to "track", "context" or "off".
+func (c *Client) setRepeat(ctx context.Context, deviceID, state string) error {
+	q := deviceQuery(deviceID)
+	q.Add("state", state)
+	return c.player(ctx, http.MethodPut, "/repeat", q, nil, nil)
+}
+
+// transferPlayback moves playback to the device, and starts it there when
+// play is set. Otherwise it keeps the current playing state.
+func (c *Client) transferPlayback(ctx context.Context, deviceID string, play bool) error {
+	body := map[string]any{"device_ids": []string{deviceID}, "play": play}
+	return c.player(ctx, http.MethodPut, "", nil, body, nil)
+}
+
+// Queue is what plays next: the items queued by the user, followed by the
+// rest of the current context.
+type Queue struct {
+	CurrentlyPlaying *PlaybackItem  `json:"currently_playing"`
+	Queue            []PlaybackItem `json:"queue"`
+}
+
+func (c *Client) getQueue(ctx context.Context) (*Queue, error) {
+	var queue Queue
+	if err := c.player(ctx, http.MethodGet, "/queue", nil, nil, &queue); err != nil {
+		return nil, err
+	}
+	return &queue, nil
+}
+
+// addToQueue queues a track or episode URI to play after the current item.
+func (c *Client) addToQueue(ctx context.Context, deviceID, uri string) error {
+	q := deviceQuery(deviceID)
+	q.Add("uri", uri)
+	return c.player(ctx, http.MethodPost, "/queue", q, nil, nil)
+}
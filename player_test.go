@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chrismeyers/spotify-cli/internal/fakespotify"
+)
+
+func newLoggedInClient(t *testing.T) (*Client, *fakespotify.Server) {
+	t.Helper()
+
+	client, server := newTestClient(t)
+	if err := client.storeRefreshToken(server.Login()); err != nil {
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+func TestPlayerNeedsLogin(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	if _, err := client.getPlaybackState(context.Background()); !errors.Is(err, errLoginRequired) {
+		t.Errorf("got %v, want errLoginRequired", err)
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	client, _ := newLoggedInClient(t)
+	ctx := context.Background()
+
+	state, err := client.getPlaybackState(ctx)
+	if err != nil || state != nil {
+		t.Fatalf("got %+v and %v, want no playback before a device is active", state, err)
+	}
+
+	devices, err := client.getDevices(ctx)
+	if err != nil || len(devices) != 3 || devices[0].IsActive {
+		t.Fatalf("got %+v and %v, want three inactive devices", devices, err)
+	}
+
+	var apiErr *APIError
+	err = client.play(ctx, "", nil)
+	if !errors.As(err, &apiErr) || apiErr.Status != 404 {
+		t.Fatalf("got %v, want a 404 without an active device", err)
+	}
+
+	results, err := client.search(ctx, SearchQuery{Q: "album:nevermind", Type: "album"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	album := results.Albums.Items[0].URI
+
+	device := fakespotify.DeviceID("Living Room")
+	steps := []struct {
+		name string
+		do   func() error
+	}{
+		{"transfer", func() error { return client.transferPlayback(ctx, device, false) }},
+		{"play", func() error { return client.play(ctx, "", playOptions(album, "")) }},
+		{"next", func() error { return client.skipNext(ctx, "") }},
+		{"next", func() error { return client.skipNext(ctx, "") }},
+		{"previous", func() error { return client.skipPrevious(ctx, "") }},
+		{"seek", func() error { return client.seek(ctx, "", time.Minute) }},
+		{"volume", func() error { return client.setVolume(ctx, device, 30) }},
+		{"shuffle", func() error { return client.setShuffle(ctx, "", true) }},
+		{"repeat", func() error { return client.setRepeat(ctx, "", "context") }},
+		{"pause", func() error { return client.pause(ctx, "") }},
+	}
+	for _, step := range steps {
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+	}
+
+	state, err = client.getPlaybackState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Device.ID != device || *state.Device.VolumePercent != 30 {
+		t.Errorf("got device %+v, want the living room at 30%%", state.Device)
+	}
+	if state.Item == nil || state.Item.Name != "In Bloom" || state.Context.URI != album {
+		t.Errorf("got item %+v, want In Bloom from Nevermind", state.Item)
+	}
+	if state.IsPlaying || state.ProgressMs < 60000 || state.ProgressMs > 61000 {
+		t.Errorf("got playing %t at %dms, want paused a minute in", state.IsPlaying, state.ProgressMs)
+	}
+	if !state.ShuffleState || state.RepeatState != "context" {
+		t.Errorf("got shuffle %t and repeat %q, want shuffle and context repeat", state.ShuffleState, state.RepeatState)
+	}
+}
+
+func TestPollInterval(t *testing.T) {
+	playing := func(progress, duration int) *PlaybackState {
+		return &PlaybackState{
+			IsPlaying:  true,
+			ProgressMs: progress,
+			Item:       &PlaybackItem{Track: Track{SimplifiedTrack: SimplifiedTrack{DurationMs: duration}}},
+		}
+	}
+	paused := playing(0, 60000)
+	paused.IsPlaying = false
+
+	tests := []struct {
+		name  string
+		state *PlaybackState
+		err   error
+		prev  time.Duration
+		want  time.Duration
+	}{
+		{"logged out", nil, errLoginRequired, 0, pollIdle},
+		{"nothing playing", nil, nil, 0, pollIdle},
+		{"paused", paused, nil, 0, pollPaused},
+		{"playing", playing(0, 60000), nil, 0, pollPlaying},
+		{"track ending", playing(58000, 60000), nil, 0, 2500 * time.Millisecond},
+		{"track ended", playing(60000, 60000), nil, 0, time.Second},
+		{"first error", nil, errors.New("boom"), pollPlaying, 10 * time.Second},
+		{"repeated errors", nil, errors.New("boom"), 90 * time.Second, pollMaxBackoff},
+		{"rate limited", nil, &APIError{Status: 429, RetryAfter: 30 * time.Second}, pollPlaying, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pollInterval(tt.state, tt.err, tt.prev); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueue(t *testing.T) {
+	client, _ := newLoggedInClient(t)
+	ctx := context.Background()
+
+	results, err := client.search(ctx, SearchQuery{Q: "album:nevermind", Type: "album,track"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	album, track := results.Albums.Items[0].URI, results.Tracks.Items[len(results.Tracks.Items)-1]
+
+	if err := client.transferPlayback(ctx, fakespotify.DeviceID("Phone"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.play(ctx, "", playOptions(album, "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.addToQueue(ctx, "", track.URI); err != nil {
+		t.Fatal(err)
+	}
+
+	queue, err := client.getQueue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queue.CurrentlyPlaying == nil || queue.CurrentlyPlaying.Name != "Smells Like Teen Spirit" {
+		t.Errorf("got %+v playing, want Smells Like Teen Spirit", queue.CurrentlyPlaying)
+	}
+	if len(queue.Queue) < 2 || queue.Queue[0].URI != track.URI || queue.Queue[1].Name != "In Bloom" {
+		t.Errorf("got queue %+v, want %s and then the rest of the album", queue.Queue, track.Name)
+	}
+
+	if err := client.skipNext(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	state, err := client.getPlaybackState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Item == nil || state.Item.URI != track.URI {
+		t.Errorf("got %+v playing after skipping, want the queued track", state.Item)
+	}
+
+	var apiErr *APIError
+	if err := client.addToQueue(ctx, "", album); !errors.As(err, &apiErr) || apiErr.Status != 400 {
+		t.Errorf("got %v, want a 400 for queueing an album", err)
+	}
+}
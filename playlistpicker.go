@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var addToPlaylistKey = key.NewBinding(
+	key.WithKeys("+"),
+	key.WithHelp("+", "add to playlist"),
+)
+
+type playlistItem struct {
+	playlist SimplifiedPlaylist
+}
+
+func (i playlistItem) Title() string { return i.playlist.Name }
+func (i playlistItem) Description() string {
+	parts := []string{categoryStyle.Render("Playlist"), fmt.Sprintf("%d items", i.playlist.Tracks.Total)}
+	if i.playlist.Public {
+		parts = append(parts, "Public")
+	} else {
+		parts = append(parts, "Private")
+	}
+	if i.playlist.Collaborative {
+		parts = append(parts, "Collaborative")
+	}
+	return strings.Join(parts, " · ")
+}
+func (i playlistItem) FilterValue() string { return i.playlist.Name }
+
+type playlistsMsg struct {
+	seq       int
+	playlists []SimplifiedPlaylist
+	err       error
+}
+
+// fetchEditablePlaylists lists every playlist of the user's that they can
+// add to: their own and the collaborative ones they follow.
+func fetchEditablePlaylists(ctx context.Context, client *Client) ([]SimplifiedPlaylist, error) {
+	user, err := client.getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var playlists []SimplifiedPlaylist
+	for offset := 0; ; {
+		page, err := client.getMyPlaylists(ctx, offset, 50)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page.Items {
+			if p.Owner.ID == user.ID || p.Collaborative {
+				playlists = append(playlists, p)
+			}
+		}
+		offset += len(page.Items)
+		if page.Next == "" || len(page.Items) == 0 {
+			return playlists, nil
+		}
+	}
+}
+
+// showPlaylists opens the picker for the playlist to add item to.
+func (m *model) showPlaylists(item resultItem) tea.Cmd {
+	if kind := uriKind(item.uri); kind != "track" && kind != "episode" {
+		return m.activeList().NewStatusMessage("Only tracks and episodes can be added to playlists")
+	}
+
+	m.playlistTarget = item
+	seq, client := m.startFetch(), m.client
+	fetch := func() tea.Msg {
+		playlists, err := fetchEditablePlaylists(context.Background(), client)
+		return playlistsMsg{seq: seq, playlists: playlists, err: err}
+	}
+	return tea.Batch(m.activeList().StartSpinner(), fetch)
+}
+
+func (m *model) updatePlaylistList(msg playlistsMsg) tea.Cmd {
+	if msg.seq != m.fetchSeq {
+		return nil
+	}
+	l := m.activeList()
+	l.StopSpinner()
+	if msg.err != nil {
+		return l.NewStatusMessage(describeError(msg.err))
+	}
+
+	if m.view != PlaylistsView {
+		m.playlistList.ResetFilter()
+		m.playlistList.Select(0)
+		m.namingPlaylist = false
+		m.pushView(PlaylistsView)
+	}
+	items := make([]list.Item, len(msg.playlists))
+	for i, p := range msg.playlists {
+		items[i] = playlistItem{playlist: p}
+	}
+	return m.playlistList.SetItems(items)
+}
+
+// addToPlaylist adds the picked item to playlist and goes back to where it
+// was picked from, which is where the outcome is shown.
+func (m *model) addToPlaylist(playlist SimplifiedPlaylist) tea.Cmd {
+	m.popView()
+
+	client, item := m.client, m.playlistTarget
+	return func() tea.Msg {
+		if _, err := client.addToPlaylist(context.Background(), playlist.ID, []string{item.uri}, -1); err != nil {
+			return actionMsg{err: errors.New(describeError(err))}
+		}
+		return actionMsg{status: fmt.Sprintf("Added %s to %s", item.name, playlist.Name)}
+	}
+}
+
+// createPlaylist creates a private playlist called name with the picked item
+// in it.
+func (m *model) createPlaylist(name string) tea.Cmd {
+	m.popView()
+	m.namingPlaylist = false
+
+	client, item := m.client, m.playlistTarget
+	return func() tea.Msg {
+		ctx := context.Background()
+		public := false
+		playlist, err := client.createPlaylist(ctx, PlaylistDetails{Name: name, Public: &public})
+		if err != nil {
+			return actionMsg{err: errors.New(describeError(err))}
+		}
+		if _, err := client.addToPlaylist(ctx, playlist.ID, []string{item.uri}, -1); err != nil {
+			return actionMsg{err: fmt.Errorf("created %s, but %s", playlist.Name, describeError(err))}
+		}
+		return actionMsg{status: fmt.Sprintf("Added %s to the new playlist %s", item.name, playlist.Name)}
+	}
+}
+
+func (m model) updatePlaylists(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.namingPlaylist {
+		switch msg.String() {
+		case "esc":
+			m.namingPlaylist = false
+			m.playlistName.Blur()
+			return m, nil
+		case "enter":
+			if name := strings.TrimSpace(m.playlistName.Value()); name != "" {
+				return m, m.createPlaylist(name)
+			}
+			return m, nil
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+		var cmd tea.Cmd
+		m.playlistName, cmd = m.playlistName.Update(msg)
+		return m, cmd
+	}
+
+	if m.playlistList.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, playlistsKeys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, playlistsKeys.Back):
+			if m.playlistList.FilterState() == list.FilterApplied && msg.String() == "esc" {
+				m.playlistList.ResetFilter()
+				return m, nil
+			}
+			m.popView()
+			return m, nil
+		case key.Matches(msg, playlistsKeys.New):
+			m.namingPlaylist = true
+			m.playlistName.Reset()
+			return m, m.playlistName.Focus()
+		case key.Matches(msg, playlistsKeys.Add):
+			if item, ok := m.playlistList.SelectedItem().(playlistItem); ok {
+				return m, m.addToPlaylist(item.playlist)
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.playlistList, cmd = m.playlistList.Update(msg)
+	return m, cmd
+}
+
+func (m model) playlistsView() string {
+	var s strings.Builder
+
+	if m.namingPlaylist {
+		s.WriteString("\n  New playlist: ")
+		s.WriteString(m.playlistName.View())
+	} else {
+		s.WriteString("\n  Add ")
+		s.WriteString(m.playlistTarget.name)
+		s.WriteString(" to")
+	}
+	s.WriteString("\n")
+	s.WriteString(m.playlistList.View())
+
+	s.WriteString("\n\n")
+	s.WriteString(m.help.View(playlistsKeys))
+
+	return s.String()
+}
+
+func newPlaylistInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Name"
+	ti.Prompt = ""
+	ti.CharLimit = 100
+	ti.Width = 40
+	return ti
+}
+
+type playlistsKeyMap struct {
+	Add  key.Binding
+	New  key.Binding
+	Back key.Binding
+	Quit key.Binding
+}
+
+func (k playlistsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Quit}
+}
+
+func (k playlistsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Add, k.New},
+		{k.Back, k.Quit},
+	}
+}
+
+var playlistsKeys = playlistsKeyMap{
+	Add: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "add to this playlist"),
+	),
+	New: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new playlist"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc/q", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
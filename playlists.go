@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// playlistBatchSize is how many items one request can add to or remove from
+// a playlist.
+const playlistBatchSize = 100
+
+type User struct {
+	DisplayName  string       `json:"display_name"`
+	ExternalUrls ExternalUrls `json:"external_urls"`
+	ID           string       `json:"id"`
+	Type         string       `json:"type"`
+	URI          string       `json:"uri"`
+}
+
+type SimplifiedPlaylist struct {
+	Collaborative bool         `json:"collaborative"`
+	Description   string       `json:"description"`
+	ExternalUrls  ExternalUrls `json:"external_urls"`
+	ID            string       `json:"id"`
+	Name          string       `json:"name"`
+	Owner         User         `json:"owner"`
+	Public        bool         `json:"public"`
+	SnapshotID    string       `json:"snapshot_id"`
+	Tracks        struct {
+		Href  string `json:"href"`
+		Total int    `json:"total"`
+	} `json:"tracks"`
+	Type string `json:"type"`
+	URI  string `json:"uri"`
+}
+
+// PlaylistDetails are the details of a playlist that can be changed. Fields
+// left as nil, or an empty Name, stay as they are.
+type PlaylistDetails struct {
+	Name          string  `json:"name,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	Public        *bool   `json:"public,omitempty"`
+	Collaborative *bool   `json:"collaborative,omitempty"`
+}
+
+type snapshotResponse struct {
+	SnapshotID string `json:"snapshot_id"`
+}
+
+// editPlaylist sends a request that changes playlist id, which needs the
+// logged in user to own it or collaborate on it.
+func (c *Client) editPlaylist(ctx context.Context, method, id, path string, body, out any) error {
+	if !c.loggedIn() {
+		return errLoginRequired
+	}
+	return c.do(ctx, method, "/playlists/"+url.PathEscape(id)+path, nil, body, out)
+}
+
+func (c *Client) getCurrentUser(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.me(ctx, http.MethodGet, "", nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// getMyPlaylists lists the playlists the user owns or follows.
+func (c *Client) getMyPlaylists(ctx context.Context, offset, limit int) (*Page[SimplifiedPlaylist], error) {
+	var page Page[SimplifiedPlaylist]
+	if err := c.me(ctx, http.MethodGet, "/playlists", pageQuery("", offset, limit), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) createPlaylist(ctx context.Context, details PlaylistDetails) (*SimplifiedPlaylist, error) {
+	user, err := c.getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var playlist SimplifiedPlaylist
+	if err := c.do(ctx, http.MethodPost, "/users/"+url.PathEscape(user.ID)+"/playlists", nil, details, &playlist); err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+func (c *Client) changePlaylistDetails(ctx context.Context, id string, details PlaylistDetails) error {
+	return c.editPlaylist(ctx, http.MethodPut, id, "", details, nil)
+}
+
+// addToPlaylist inserts uris at position, or appends them when position is
+// negative, and returns the playlist's new snapshot ID. When a batch fails,
+// the snapshot after the batches that went through is returned with the error.
+func (c *Client) addToPlaylist(ctx context.Context, id string, uris []string, position int) (string, error) {
+	var snapshot string
+	added := 0
+	for batch := range slices.Chunk(uris, playlistBatchSize) {
+		body := struct {
+			URIs     []string `json:"uris"`
+			Position *int     `json:"position,omitempty"`
+		}{URIs: batch}
+		if position >= 0 {
+			at := position + added
+			body.Position = &at
+		}
+
+		var resp snapshotResponse
+		if err := c.editPlaylist(ctx, http.MethodPost, id, "/tracks", body, &resp); err != nil {
+			return snapshot, err
+		}
+		snapshot = resp.SnapshotID
+		added += len(batch)
+	}
+	return snapshot, nil
+}
+
+// removeFromPlaylist removes every occurrence of uris from the playlist as it
+// was at snapshotID, or as it is now when that's empty. Each batch applies to
+// the snapshot the one before it left, and the last is returned like
+// addToPlaylist does.
+func (c *Client) removeFromPlaylist(ctx context.Context, id, snapshotID string, uris []string) (string, error) {
+	type trackURI struct {
+		URI string `json:"uri"`
+	}
+
+	snapshot := snapshotID
+	for batch := range slices.Chunk(uris, playlistBatchSize) {
+		body := struct {
+			Tracks     []trackURI `json:"tracks"`
+			SnapshotID string     `json:"snapshot_id,omitempty"`
+		}{SnapshotID: snapshot}
+		for _, uri := range batch {
+			body.Tracks = append(body.Tracks, trackURI{uri})
+		}
+
+		var resp snapshotResponse
+		if err := c.editPlaylist(ctx, http.MethodDelete, id, "/tracks", body, &resp); err != nil {
+			return snapshot, err
+		}
+		snapshot = resp.SnapshotID
+	}
+	return snapshot, nil
+}
+
+// reorderPlaylist moves length items starting at start to before the item at
+// insertBefore, where indexes are those before the move.
+func (c *Client) reorderPlaylist(ctx context.Context, id, snapshotID string, start, length, insertBefore int) (string, error) {
+	body := struct {
+		RangeStart   int    `json:"range_start"`
+		InsertBefore int    `json:"insert_before"`
+		RangeLength  int    `json:"range_length"`
+		SnapshotID   string `json:"snapshot_id,omitempty"`
+	}{start, insertBefore, length, snapshotID}
+
+	var resp snapshotResponse
+	if err := c.editPlaylist(ctx, http.MethodPut, id, "/tracks", body, &resp); err != nil {
+		return "", err
+	}
+	return resp.SnapshotID, nil
+}
+
+// parseSpotifyID returns the ID in a spotify: URI or open.spotify.com URL of
+// the given kind, like "playlist", or s itself when it's a bare ID.
+func parseSpotifyID(kind, s string) (string, bool) {
+	if uriKind(s) != "" {
+		if uriKind(s) != kind {
+			return "", false
+		}
+		return s[strings.LastIndex(s, ":")+1:], true
+	}
+	if u, err := url.Parse(s); err == nil && u.Host == "open.spotify.com" {
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) < 2 || parts[len(parts)-2] != kind {
+			return "", false
+		}
+		return parts[len(parts)-1], true
+	}
+	if s == "" || strings.ContainsAny(s, ":/") {
+		return "", false
+	}
+	return s, true
+}
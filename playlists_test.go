@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func playlistURIs(t *testing.T, client *Client, id string) []string {
+	t.Helper()
+
+	var uris []string
+	for offset := 0; ; offset += 100 {
+		page, err := client.getPlaylistTracks(context.Background(), id, "", offset, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range page.Items {
+			uris = append(uris, item.Track.URI)
+		}
+		if page.Next == "" {
+			return uris
+		}
+	}
+}
+
+func TestPlaylists(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+	if _, err := client.createPlaylist(ctx, PlaylistDetails{Name: "Mixtape"}); !errors.Is(err, errLoginRequired) {
+		t.Fatalf("got %v, want errLoginRequired", err)
+	}
+
+	client, server := newLoggedInClient(t)
+	private := false
+	playlist, err := client.createPlaylist(ctx, PlaylistDetails{Name: "Mixtape", Public: &private})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := client.getMyPlaylists(ctx, 0, 50)
+	if err != nil || len(page.Items) != 2 || page.Items[0].ID != playlist.ID || page.Items[0].Public {
+		t.Fatalf("got %+v and %v, want the new private playlist first", page, err)
+	}
+
+	results, err := client.search(ctx, SearchQuery{Q: "nirvana", Type: "track"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tracks []string
+	for _, track := range results.Tracks.Items {
+		tracks = append(tracks, track.URI)
+	}
+
+	// 150 items take two requests of 100.
+	uris := make([]string, 150)
+	for i := range uris {
+		uris[i] = tracks[i%len(tracks)]
+	}
+	before := server.APICalls()
+	snapshot, err := client.addToPlaylist(ctx, playlist.ID, uris, -1)
+	if err != nil || snapshot == "" {
+		t.Fatalf("got %q and %v, want a new snapshot", snapshot, err)
+	}
+	if calls := server.APICalls() - before; calls != 2 {
+		t.Errorf("got %d API calls, want 2", calls)
+	}
+	if got := playlistURIs(t, client, playlist.ID); len(got) != 150 {
+		t.Fatalf("got %d items, want 150", len(got))
+	}
+
+	if _, err := client.removeFromPlaylist(ctx, playlist.ID, playlist.SnapshotID, tracks[:1]); err == nil {
+		t.Error("removed items from a stale snapshot")
+	}
+	if snapshot, err = client.removeFromPlaylist(ctx, playlist.ID, snapshot, tracks[1:]); err != nil {
+		t.Fatal(err)
+	}
+	want := 0
+	for _, uri := range uris {
+		if uri == tracks[0] {
+			want++
+		}
+	}
+	got := playlistURIs(t, client, playlist.ID)
+	if len(got) != want || got[0] != tracks[0] || got[want-1] != tracks[0] {
+		t.Fatalf("got %v, want only the first track left", got)
+	}
+
+	if snapshot, err = client.addToPlaylist(ctx, playlist.ID, tracks[1:3], 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.reorderPlaylist(ctx, playlist.ID, snapshot, 0, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got := playlistURIs(t, client, playlist.ID); got[0] != tracks[0] || got[1] != tracks[1] || got[2] != tracks[2] {
+		t.Errorf("got %v, want the two added tracks moved after the first", got[:3])
+	}
+
+	description := "Songs for the car"
+	if err := client.changePlaylistDetails(ctx, playlist.ID, PlaylistDetails{Name: "Car Mix", Description: &description}); err != nil {
+		t.Fatal(err)
+	}
+	page, err = client.getMyPlaylists(ctx, 0, 1)
+	if err != nil || page.Items[0].Name != "Car Mix" || page.Items[0].Description != description {
+		t.Errorf("got %+v and %v, want the playlist renamed", page, err)
+	}
+
+	catalog, err := client.search(ctx, SearchQuery{Q: "grunge", Type: "playlist"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *APIError
+	_, err = client.addToPlaylist(ctx, catalog.Playlists.Items[0].ID, tracks[:1], -1)
+	if !errors.As(err, &apiErr) || apiErr.Status != 403 {
+		t.Errorf("got %v, want a 403 for someone else's playlist", err)
+	}
+}
+
+func TestParseSpotifyID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"37i9dQZF1DXbTxeAdrVG2l", "37i9dQZF1DXbTxeAdrVG2l", true},
+		{"spotify:playlist:37i9dQZF1DXbTxeAdrVG2l", "37i9dQZF1DXbTxeAdrVG2l", true},
+		{"https://open.spotify.com/playlist/37i9dQZF1DXbTxeAdrVG2l?si=abc", "37i9dQZF1DXbTxeAdrVG2l", true},
+		{"https://open.spotify.com/intl-de/playlist/37i9dQZF1DXbTxeAdrVG2l", "37i9dQZF1DXbTxeAdrVG2l", true},
+		{"spotify:album:37i9dQZF1DXbTxeAdrVG2l", "", false},
+		{"https://open.spotify.com/album/37i9dQZF1DXbTxeAdrVG2l", "", false},
+		{"https://example.com/playlist/x", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSpotifyID("playlist", tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseSpotifyID(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
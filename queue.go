@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	queueKey = key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "add to queue"),
+	)
+	showQueueKey = key.NewBinding(
+		key.WithKeys("Q"),
+		key.WithHelp("Q", "show queue"),
+	)
+)
+
+type queueMsg struct {
+	seq   int
+	queue *Queue
+	err   error
+}
+
+func playbackItem(item PlaybackItem) resultItem {
+	if item.Show != nil {
+		return resultItem{
+			category: "Episode",
+			id:       item.ID,
+			name:     item.Name,
+			detail:   fmt.Sprintf("%s · %s", formatDuration(item.DurationMs), item.Show.Name),
+			url:      item.ExternalUrls.Spotify,
+			uri:      item.URI,
+		}
+	}
+	return trackItem(item.SimplifiedTrack, "Album: "+item.Album.Name)
+}
+
+func (m *model) queueItem(item resultItem) tea.Cmd {
+	if kind := uriKind(item.uri); kind != "track" && kind != "episode" {
+		return m.activeList().NewStatusMessage("Only tracks and episodes can be queued")
+	}
+
+	client, device := m.client, m.client.Config.Player.Device
+	return func() tea.Msg {
+		if err := client.addToQueue(context.Background(), device, item.uri); err != nil {
+			return actionMsg{err: errors.New(describeError(err))}
+		}
+		return actionMsg{status: "Queued " + item.name}
+	}
+}
+
+func (m *model) showQueue() tea.Cmd {
+	seq, client := m.startFetch(), m.client
+	fetch := func() tea.Msg {
+		queue, err := client.getQueue(context.Background())
+		return queueMsg{seq: seq, queue: queue, err: err}
+	}
+	return tea.Batch(m.activeList().StartSpinner(), fetch)
+}
+
+func (m *model) updateQueueList(msg queueMsg) tea.Cmd {
+	if msg.seq != m.fetchSeq {
+		return nil
+	}
+	l := m.activeList()
+	l.StopSpinner()
+	if msg.err != nil {
+		return l.NewStatusMessage(describeError(msg.err))
+	}
+
+	var items []list.Item
+	for _, item := range msg.queue.Queue {
+		items = append(items, playbackItem(item))
+	}
+	m.queueHeader = "Nothing playing"
+	if current := msg.queue.CurrentlyPlaying; current != nil {
+		m.queueHeader = "Now playing: " + playbackItem(*current).name
+	}
+
+	if m.view != QueueView {
+		m.queueList.ResetFilter()
+		m.queueList.Select(0)
+		m.pushView(QueueView)
+	}
+	return tea.Batch(m.queueList.SetItems(items), m.checkSaved(items))
+}
+
+func (m model) updateQueue(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.queueList.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, queueKeys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, queueKeys.Back):
+			if m.queueList.FilterState() == list.FilterApplied && msg.String() == "esc" {
+				m.queueList.ResetFilter()
+				return m, nil
+			}
+			m.popView()
+			return m, nil
+		case key.Matches(msg, queueKeys.Refresh):
+			return m, m.showQueue()
+		case key.Matches(msg, queueKeys.ShowDevices):
+			return m, m.showDevices()
+		}
+		if cmd, ok := m.itemAction(msg); ok {
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.queueList, cmd = m.queueList.Update(msg)
+	return m, cmd
+}
+
+func (m model) queueView() string {
+	var s strings.Builder
+
+	s.WriteString("\n  ")
+	s.WriteString(m.queueHeader)
+	s.WriteString("\n")
+	s.WriteString(m.queueList.View())
+
+	s.WriteString("\n\n")
+	s.WriteString(m.help.View(queueKeys))
+
+	return s.String()
+}
+
+type queueKeyMap struct {
+	Refresh       key.Binding
+	ShowDevices   key.Binding
+	Play          key.Binding
+	Queue         key.Binding
+	Save          key.Binding
+	AddToPlaylist key.Binding
+	Bookmark      key.Binding
+	OpenWeb       key.Binding
+	OpenApp       key.Binding
+	CopyURL       key.Binding
+	CopyURI       key.Binding
+	CopyID        key.Binding
+	Back          key.Binding
+	Quit          key.Binding
+}
+
+func (k queueKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Quit}
+}
+
+func (k queueKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Refresh, k.ShowDevices},
+		{k.Play, k.Queue, k.Save, k.AddToPlaylist, k.Bookmark, k.OpenWeb, k.OpenApp, k.CopyURL, k.CopyURI, k.CopyID},
+		{k.Back, k.Quit},
+	}
+}
+
+var queueKeys = queueKeyMap{
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+	ShowDevices:   showDevicesKey,
+	Play:          playKey,
+	Queue:         queueKey,
+	Save:          saveKey,
+	AddToPlaylist: addToPlaylistKey,
+	Bookmark:      bookmarkKey,
+	OpenWeb:       openWebKey,
+	OpenApp:       openAppKey,
+	CopyURL:       copyURLKey,
+	CopyURI:       copyURIKey,
+	CopyID:        copyIDKey,
+	Back: key.NewBinding(
+		key.WithKeys("esc", "q"),
+		key.WithHelp("esc/q", "go back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var saveKey = key.NewBinding(
+	key.WithKeys("s"),
+	key.WithHelp("s", "save/unsave"),
+)
+
+// savedMsg reports which items, by URI, are saved in the user's library.
+type savedMsg struct {
+	saved  map[string]bool
+	status string
+	err    error
+}
+
+// checkSaved looks up which of items are saved, for those that haven't
+// been looked up yet. IDs are checked in batches, one request per
+// collection and batch.
+func (m *model) checkSaved(items []list.Item) tea.Cmd {
+	if !m.client.loggedIn() {
+		return nil
+	}
+
+	ids := map[string][]string{}
+	uris := map[string][]string{}
+	for _, it := range items {
+		item, ok := it.(resultItem)
+		if !ok || item.id == "" {
+			continue
+		}
+		collection := libraryCollection(item.category)
+		if _, known := m.saved[item.uri]; collection == "" || known {
+			continue
+		}
+		ids[collection] = append(ids[collection], item.id)
+		uris[collection] = append(uris[collection], item.uri)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	client := m.client
+	return func() tea.Msg {
+		saved := map[string]bool{}
+		for collection := range ids {
+			contains, err := client.librarySaved(context.Background(), collection, ids[collection])
+			// Without the hearts the results are still usable, so
+			// failed checks are left out rather than reported.
+			if err != nil {
+				continue
+			}
+			for i, uri := range uris[collection] {
+				saved[uri] = contains[i]
+			}
+		}
+		return savedMsg{saved: saved}
+	}
+}
+
+func (m *model) toggleSaved(item resultItem) tea.Cmd {
+	collection := libraryCollection(item.category)
+	if collection == "" || item.id == "" {
+		return m.activeList().NewStatusMessage("Only tracks, albums, shows, episodes and audiobooks can be saved")
+	}
+
+	client := m.client
+	save := !m.saved[item.uri]
+	return func() tea.Msg {
+		ctx := context.Background()
+		if save {
+			if err := client.saveToLibrary(ctx, collection, []string{item.id}); err != nil {
+				return savedMsg{err: errors.New(describeError(err))}
+			}
+			return savedMsg{saved: map[string]bool{item.uri: true}, status: "Saved " + item.name}
+		}
+		if err := client.removeFromLibrary(ctx, collection, []string{item.id}); err != nil {
+			return savedMsg{err: errors.New(describeError(err))}
+		}
+		return savedMsg{saved: map[string]bool{item.uri: false}, status: "Removed " + item.name + " from your library"}
+	}
+}
+
+// updateSaved records what msg says is saved and updates the hearts of every
+// list showing those items.
+func (m *model) updateSaved(msg savedMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.activeList().NewStatusMessage(msg.err.Error())
+	}
+
+	for uri, saved := range msg.saved {
+		m.saved[uri] = saved
+	}
+	for i := range m.pages {
+		for j, item := range m.pages[i].items {
+			m.pages[i].items[j] = m.markSaved(item)
+		}
+	}
+
+	var cmds []tea.Cmd
+	lists := []*list.Model{&m.resultList, &m.queueList, &m.libraryList}
+	for i := range m.details {
+		lists = append(lists, &m.details[i].list)
+	}
+	for _, l := range lists {
+		for i, item := range l.Items() {
+			cmds = append(cmds, l.SetItem(i, m.markSaved(item)))
+		}
+	}
+
+	if msg.status != "" {
+		cmds = append(cmds, m.activeList().NewStatusMessage(msg.status))
+	}
+	return tea.Batch(cmds...)
+}
+
+// markSaved sets whether a result is saved from what's been looked up so far.
+func (m model) markSaved(it list.Item) list.Item {
+	if item, ok := it.(resultItem); ok {
+		item.saved = m.saved[item.uri]
+		return item
+	}
+	return it
+}
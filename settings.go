@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchFocus is the section of the search view that receives key presses.
+// Tab moves through them in order.
+type searchFocus int
+
+const (
+	inputFocus searchFocus = iota
+	categoriesFocus
+	settingsFocus
+	searchFocusCount
+)
+
+const (
+	marketSetting = iota
+	limitSetting
+	externalSetting
+	settingCount
+)
+
+const maxSearchLimit = 50
+
+// searchLimit returns the configured results per page, falling back to
+// resultsPageSize when it's unset or out of range.
+func searchLimit(limit int) int {
+	if limit < 1 || limit > maxSearchLimit {
+		return resultsPageSize
+	}
+	return limit
+}
+
+type marketsMsg struct {
+	markets []string
+	err     error
+}
+
+// loadMarkets fetches the markets to pick from the first time the settings
+// are focused.
+func (m *model) loadMarkets() tea.Cmd {
+	if m.markets != nil || m.loadingMkts {
+		return nil
+	}
+	m.loadingMkts = true
+
+	client := m.client
+	return func() tea.Msg {
+		markets, err := client.getMarkets(context.Background())
+		return marketsMsg{markets: markets, err: err}
+	}
+}
+
+// updateSettings handles the keys that move between and change settings,
+// reporting whether msg was one of them.
+func (m *model) updateSettings(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, settingsKeys.Up):
+		m.setting = max(m.setting-1, 0)
+		return nil, true
+	case key.Matches(msg, settingsKeys.Down):
+		m.setting = min(m.setting+1, settingCount-1)
+		return nil, true
+	case key.Matches(msg, settingsKeys.Decrease):
+		return m.changeSetting(-1), true
+	case key.Matches(msg, settingsKeys.Increase):
+		return m.changeSetting(1), true
+	case key.Matches(msg, settingsKeys.DecreaseMore):
+		return m.changeSetting(-10), true
+	case key.Matches(msg, settingsKeys.IncreaseMore):
+		return m.changeSetting(10), true
+	}
+	return nil, false
+}
+
+func (m *model) changeSetting(delta int) tea.Cmd {
+	settings := &m.client.Config.Search
+
+	switch m.setting {
+	case marketSetting:
+		if m.markets == nil {
+			return m.loadMarkets()
+		}
+		// The empty market leaves it to Spotify, which matches any market
+		// for app tokens and the account's country for user tokens.
+		options := append([]string{""}, m.markets...)
+		i := max(slices.Index(options, settings.Market), 0)
+		settings.Market = options[((i+delta)%len(options)+len(options))%len(options)]
+	case limitSetting:
+		settings.Limit = min(max(searchLimit(settings.Limit)+delta, 1), maxSearchLimit)
+	case externalSetting:
+		settings.IncludeExternal = !settings.IncludeExternal
+	}
+
+	m.settingsChanged = true
+	return nil
+}
+
+// saveSettings writes changed settings to the config file. It's done once
+// the settings are left rather than on every change, which would rewrite
+// the file with each key press.
+func (m *model) saveSettings() error {
+	if !m.settingsChanged {
+		return nil
+	}
+	m.settingsChanged = false
+	return m.client.Config.saveSearch()
+}
+
+const sliderWidth = 25
+
+func (m model) settingsView() string {
+	var s strings.Builder
+
+	titleStyle := normalTitleStyle
+	if m.focus == settingsFocus {
+		titleStyle = focusedTitleStyle
+	}
+	s.WriteString(titleStyle.Render("Settings:"))
+	s.WriteString("\n")
+
+	settings := m.client.Config.Search
+
+	market := settings.Market
+	if market == "" {
+		market = "any"
+	}
+	if m.loadingMkts {
+		market += " (loading markets...)"
+	}
+
+	limit := searchLimit(settings.Limit)
+	filled := limit * sliderWidth / maxSearchLimit
+	slider := strings.Repeat("█", filled) + strings.Repeat("░", sliderWidth-filled)
+
+	external := " "
+	if settings.IncludeExternal {
+		external = "x"
+	}
+
+	rows := []string{
+		fmt.Sprintf("Market            ‹ %s ›", market),
+		fmt.Sprintf("Results per page  %s %d", slider, limit),
+		fmt.Sprintf("External audio    [%s]", external),
+	}
+	for i, row := range rows {
+		cursor := " "
+		if m.setting == i && m.focus == settingsFocus {
+			cursor = ">"
+		}
+		s.WriteString(fmt.Sprintf("%s %s\n", cursor, row))
+	}
+
+	return s.String()
+}
+
+type settingsKeyMap struct {
+	Up           key.Binding
+	Down         key.Binding
+	Decrease     key.Binding
+	Increase     key.Binding
+	DecreaseMore key.Binding
+	IncreaseMore key.Binding
+	Toggle       key.Binding
+	Search       key.Binding
+	Quit         key.Binding
+}
+
+func (k settingsKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Quit}
+}
+
+func (k settingsKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Decrease, k.Increase},
+		{k.Toggle, k.Search, k.Quit},
+	}
+}
+
+var settingsKeys = settingsKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Decrease: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/h", "previous value"),
+	),
+	Increase: key.NewBinding(
+		key.WithKeys("right", "l", " "),
+		key.WithHelp("→/l", "next value"),
+	),
+	DecreaseMore: key.NewBinding(
+		key.WithKeys("shift+left", "H"),
+	),
+	IncreaseMore: key.NewBinding(
+		key.WithKeys("shift+right", "L"),
+	),
+	Toggle: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next section"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "submit search"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
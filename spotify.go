@@ -1,21 +1,28 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type RawToken struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int    `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
 }
 
 type Token struct {
@@ -23,8 +30,181 @@ type Token struct {
 	Expiration int `json:"expiration"`
 }
 
+type ErrorKind int
+
+const (
+	UnknownError ErrorKind = iota
+	AuthError
+	RateLimitError
+	NetworkError
+	DecodeError
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case AuthError:
+		return "authentication error"
+	case RateLimitError:
+		return "rate limited"
+	case NetworkError:
+		return "network error"
+	case DecodeError:
+		return "decode error"
+	default:
+		return "api error"
+	}
+}
+
+type APIError struct {
+	Kind       ErrorKind
+	Status     int
+	Message    string
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	var s strings.Builder
+	s.WriteString("spotify: ")
+	s.WriteString(e.Kind.String())
+	if e.Status != 0 {
+		s.WriteString(fmt.Sprintf(" (%d)", e.Status))
+	}
+	if e.Message != "" {
+		s.WriteString(": ")
+		s.WriteString(e.Message)
+	}
+	if e.Err != nil {
+		s.WriteString(": ")
+		s.WriteString(e.Err.Error())
+	}
+	return s.String()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// errorBody covers both error shapes Spotify returns: the regular API's
+// {"error":{"status":...,"message":...}} and the accounts service's
+// {"error":"...","error_description":"..."}.
+type errorBody struct {
+	Error            json.RawMessage `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+}
+
+func newResponseError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{Status: resp.StatusCode}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		apiErr.Kind = AuthError
+	case resp.StatusCode == http.StatusTooManyRequests:
+		apiErr.Kind = RateLimitError
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+	default:
+		apiErr.Kind = UnknownError
+	}
+
+	var eb errorBody
+	if err := json.Unmarshal(body, &eb); err == nil && len(eb.Error) > 0 {
+		var regular struct {
+			Status  int    `json:"status"`
+			Message string `json:"message"`
+		}
+		var code string
+		if err := json.Unmarshal(eb.Error, &regular); err == nil {
+			apiErr.Message = regular.Message
+		} else if err := json.Unmarshal(eb.Error, &code); err == nil {
+			apiErr.Message = code
+			if eb.ErrorDescription != "" {
+				apiErr.Message = code + ": " + eb.ErrorDescription
+			}
+			if code == "invalid_client" || code == "invalid_grant" || code == "unauthorized_client" {
+				apiErr.Kind = AuthError
+			}
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return apiErr
+}
+
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &APIError{Kind: NetworkError, Err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &APIError{Kind: NetworkError, Status: resp.StatusCode, Err: err}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newResponseError(resp, respBody)
+	}
+
+	return respBody, nil
+}
+
+const (
+	defaultAPIURL      = "https://api.spotify.com/v1"
+	defaultAccountsURL = "https://accounts.spotify.com"
+	defaultTimeout     = 30 * time.Second
+	defaultMaxAttempts = 4
+	defaultRetryBudget = 30 * time.Second
+	defaultBackoffBase = 500 * time.Millisecond
+)
+
 type Client struct {
-	Config Config
+	Config      Config
+	HTTPClient  *http.Client
+	APIURL      string
+	AccountsURL string
+
+	// OnRetry, when set, is called before the client waits to retry a
+	// failed request.
+	OnRetry func(RetryEvent)
+
+	maxAttempts int
+	retryBudget time.Duration
+	backoffBase time.Duration
+
+	// tokenMu serializes reading and refreshing the token file, so that
+	// concurrent requests don't each spend the same refresh token.
+	tokenMu sync.Mutex
+	// appToken stands in for the user's token while Spotify refuses
+	// refusedToken, their refresh token, which stays on disk until they log
+	// in again.
+	appToken     *Token
+	refusedToken string
+}
+
+type ClientOption func(*Client)
+
+// WithHTTPClient makes the client send every request through hc, which
+// takes precedence over the timeout and proxy settings in Config.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient = hc
+	}
+}
+
+// WithTransport keeps the configured timeout but sends requests through rt.
+// It changes a copy of the HTTP client, leaving one given to WithHTTPClient
+// as it was.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		hc := *c.HTTPClient
+		hc.Transport = rt
+		c.HTTPClient = &hc
+	}
 }
 
 type SearchQuery struct {
@@ -349,37 +529,175 @@ type SearchResults struct {
 	} `json:"audiobooks"`
 }
 
-func NewClient(config Config) Client {
-	return Client{Config: config}
+func NewClient(config Config, opts ...ClientOption) (*Client, error) {
+	timeout := defaultTimeout
+	if config.API.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(config.API.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api.timeout: %w", err)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.API.Proxy != "" {
+		proxyURL, err := url.Parse(config.API.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api.proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	c := &Client{
+		Config:      config,
+		HTTPClient:  &http.Client{Timeout: timeout, Transport: transport},
+		APIURL:      defaultAPIURL,
+		AccountsURL: defaultAccountsURL,
+		maxAttempts: defaultMaxAttempts,
+		retryBudget: defaultRetryBudget,
+		backoffBase: defaultBackoffBase,
+	}
+	if config.API.MaxAttempts > 0 {
+		c.maxAttempts = config.API.MaxAttempts
+	}
+	if config.API.RetryBudget != "" {
+		budget, err := time.ParseDuration(config.API.RetryBudget)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api.retryBudget: %w", err)
+		}
+		c.retryBudget = budget
+	}
+	if config.API.BaseURL != "" {
+		c.APIURL = strings.TrimSuffix(config.API.BaseURL, "/")
+	}
+	if config.API.AccountsURL != "" {
+		c.AccountsURL = strings.TrimSuffix(config.API.AccountsURL, "/")
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+func (c *Client) readToken() (*Token, error) {
+	data, err := os.ReadFile(c.Config.TokenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cachedToken Token
+	err = json.Unmarshal(data, &cachedToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachedToken, nil
+}
+
+// loggedIn reports whether there's a user login that Spotify still accepts.
+func (c *Client) loggedIn() bool {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	token, err := c.readToken()
+	return err == nil && token != nil && token.RefreshToken != "" && token.RefreshToken != c.refusedToken
+}
+
+func (c *Client) writeToken(token *Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.Config.TokenPath, data)
+}
+
+// storeToken replaces the token file with token.
+func (c *Client) storeToken(token *Token) error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.writeToken(token)
+}
+
+// storeRefreshToken saves a refresh token obtained elsewhere. It's exchanged
+// for an access token on the next request.
+func (c *Client) storeRefreshToken(refreshToken string) error {
+	return c.storeToken(&Token{RawToken: RawToken{RefreshToken: refreshToken}})
 }
 
-func (c *Client) fetchToken() (*Token, error) {
-	if _, err := os.Stat(c.Config.TokenPath); !errors.Is(err, os.ErrNotExist) {
-		data, err := os.ReadFile(c.Config.TokenPath)
+func (c *Client) fetchToken(ctx context.Context) (*Token, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	cachedToken, err := c.readToken()
+	if err != nil {
+		return nil, err
+	}
+	now := int(time.Now().Unix())
+
+	if cachedToken != nil && cachedToken.Expiration >= now {
+		return cachedToken, nil
+	}
+
+	if cachedToken == nil || cachedToken.RefreshToken == "" {
+		token, err := c.appOnlyToken(ctx)
 		if err != nil {
 			return nil, err
 		}
+		return token, c.writeToken(token)
+	}
 
-		var cachedToken Token
-		err = json.Unmarshal(data, &cachedToken)
-		if err != nil {
+	if cachedToken.RefreshToken != c.refusedToken {
+		token, err := c.refreshToken(ctx, cachedToken.RefreshToken)
+		if err == nil {
+			return token, c.writeToken(token)
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Kind != AuthError {
 			return nil, err
 		}
+		// The refresh token was revoked, so drop back to an app-only token
+		// until the user logs in again. It's kept in memory only, as
+		// writing it out would log the user out.
+		c.refusedToken = cachedToken.RefreshToken
+		c.appToken = nil
+	}
 
-		if cachedToken.Expiration >= int(time.Now().Unix()) {
-			return &cachedToken, nil
+	if c.appToken == nil || c.appToken.Expiration < now {
+		token, err := c.appOnlyToken(ctx)
+		if err != nil {
+			return nil, err
 		}
+		c.appToken = token
 	}
+	return c.appToken, nil
+}
 
-	reqBody := url.Values{
+func (c *Client) appOnlyToken(ctx context.Context) (*Token, error) {
+	return c.requestToken(ctx, url.Values{
 		"grant_type":    []string{"client_credentials"},
 		"client_id":     []string{c.Config.API.ClientID},
 		"client_secret": []string{c.Config.API.ClientSecret},
-	}
+	}, "")
+}
 
-	req, err := http.NewRequest(
+func (c *Client) refreshToken(ctx context.Context, refreshToken string) (*Token, error) {
+	return c.requestToken(ctx, url.Values{
+		"grant_type":    []string{"refresh_token"},
+		"refresh_token": []string{refreshToken},
+		"client_id":     []string{c.Config.API.ClientID},
+	}, refreshToken)
+}
+
+func (c *Client) requestToken(ctx context.Context, reqBody url.Values, refreshToken string) (*Token, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
-		"https://accounts.spotify.com/api/token",
+		c.AccountsURL+"/api/token",
 		strings.NewReader(reqBody.Encode()),
 	)
 	if err != nil {
@@ -388,14 +706,7 @@ func (c *Client) fetchToken() (*Token, error) {
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := doRequest(c.HTTPClient, req)
 	if err != nil {
 		return nil, err
 	}
@@ -403,41 +714,137 @@ func (c *Client) fetchToken() (*Token, error) {
 	var rawToken RawToken
 	err = json.Unmarshal(respBody, &rawToken)
 	if err != nil {
-		return nil, err
+		return nil, &APIError{Kind: DecodeError, Err: err}
 	}
 
-	token := Token{
+	// Spotify may omit the refresh token when refreshing, in which case the
+	// one we already have stays valid.
+	if rawToken.RefreshToken == "" {
+		rawToken.RefreshToken = refreshToken
+	}
+
+	return &Token{
 		RawToken:   rawToken,
 		Expiration: int(time.Now().Unix()) + rawToken.ExpiresIn - 15,
+	}, nil
+}
+
+type RetryEvent struct {
+	Attempt int
+	Wait    time.Duration
+	Err     error
+}
+
+// retryDelay reports whether a failed request is worth retrying and how long
+// to wait first: rate limits wait for Retry-After, server and network errors
+// back off exponentially with jitter. A POST may have been carried out before
+// a server or network error, so it is only retried when the API turned it away
+// with a Retry-After.
+func (c *Client) retryDelay(ctx context.Context, method string, err error, attempt int) (time.Duration, bool) {
+	var apiErr *APIError
+	if ctx.Err() != nil || !errors.As(err, &apiErr) {
+		return 0, false
 	}
 
-	tokenStr, err := json.Marshal(token)
-	if err != nil {
-		return nil, err
+	switch {
+	case apiErr.Kind == RateLimitError && apiErr.RetryAfter > 0:
+		return apiErr.RetryAfter, true
+	case method == http.MethodPost:
+		return 0, false
+	case apiErr.Kind == RateLimitError, apiErr.Kind == NetworkError, apiErr.Status >= 500:
+		backoff := c.backoffBase << attempt
+		return backoff/2 + rand.N(backoff/2+1), true
 	}
 
-	err = os.WriteFile(c.Config.TokenPath, tokenStr, 0644)
-	if err != nil {
-		return nil, err
+	return 0, false
+}
+
+type noRetriesKey struct{}
+
+// withoutRetries makes requests made with ctx give up after the first
+// failure, for callers that back off on their own.
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesKey{}, true)
+}
+
+// do sends an authorized request to the Web API, retrying within the
+// client's retry budget, and decodes the response body into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	maxAttempts := c.maxAttempts
+	if ctx.Value(noRetriesKey{}) != nil {
+		maxAttempts = 1
 	}
 
-	return &token, nil
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		respBody, err := c.send(ctx, method, path, query, payload)
+		if err == nil {
+			if out == nil || len(respBody) == 0 {
+				return nil
+			}
+			if err := json.Unmarshal(respBody, out); err != nil {
+				return &APIError{Kind: DecodeError, Err: err}
+			}
+			return nil
+		}
+
+		wait, retry := c.retryDelay(ctx, method, err, attempt)
+		if !retry || attempt+1 >= maxAttempts || waited+wait > c.retryBudget {
+			return err
+		}
+		waited += wait
+
+		if c.OnRetry != nil {
+			c.OnRetry(RetryEvent{Attempt: attempt + 1, Wait: wait, Err: err})
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 }
 
-func (c *Client) search(s SearchQuery) (*SearchResults, error) {
-	token, err := c.fetchToken()
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, payload []byte) ([]byte, error) {
+	token, err := c.fetchToken(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("GET", "https://api.spotify.com/v1/search", nil)
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.APIURL+path, body)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.URL.RawQuery = query.Encode()
 
-	q := req.URL.Query()
+	return doRequest(c.HTTPClient, req)
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, out)
+}
+
+func (c *Client) search(ctx context.Context, s SearchQuery) (*SearchResults, error) {
+	q := url.Values{}
 	q.Add("q", s.Q)
 	q.Add("type", s.Type)
 	if s.Market != "" {
@@ -452,22 +859,9 @@ func (c *Client) search(s SearchQuery) (*SearchResults, error) {
 	if s.IncludeExternal != "" {
 		q.Add("include_external", s.IncludeExternal)
 	}
-	req.URL.RawQuery = q.Encode()
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
 
 	var results SearchResults
-	err = json.Unmarshal(respBody, &results)
+	err := c.get(ctx, "/search", q, &results)
 	if err != nil {
 		return nil, err
 	}
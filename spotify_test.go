@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chrismeyers/spotify-cli/internal/fakespotify"
+)
+
+func newTestClient(t *testing.T) (*Client, *fakespotify.Server) {
+	t.Helper()
+
+	server := fakespotify.New()
+	t.Cleanup(server.Close)
+
+	var config Config
+	config.API.ClientID = fakespotify.ClientID
+	config.API.ClientSecret = fakespotify.ClientSecret
+	config.API.BaseURL = server.APIURL()
+	config.API.AccountsURL = server.AccountsURL()
+	config.TokenPath = filepath.Join(t.TempDir(), "token.json")
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.backoffBase = time.Millisecond
+
+	return client, server
+}
+
+func TestSearch(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	results, err := client.search(context.Background(), SearchQuery{Q: "nirvana", Type: "album,track"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.Albums.Total != 2 {
+		t.Errorf("got %d albums, want 2", results.Albums.Total)
+	}
+	if results.Tracks.Total != 8 {
+		t.Errorf("got %d tracks, want 8", results.Tracks.Total)
+	}
+	if results.Artists.Href != "" {
+		t.Error("got artists, but they weren't requested")
+	}
+	if got := results.Tracks.Items[0].Name; got != "Smells Like Teen Spirit" {
+		t.Errorf("got first track %q, want %q", got, "Smells Like Teen Spirit")
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	first, err := client.search(context.Background(), SearchQuery{Q: "nirvana", Type: "track", Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Tracks.Items) != 5 || first.Tracks.Next == "" {
+		t.Fatalf("got %d items and next %q, want 5 items and a next page", len(first.Tracks.Items), first.Tracks.Next)
+	}
+
+	second, err := client.search(context.Background(), SearchQuery{Q: "nirvana", Type: "track", Limit: 5, Offset: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Tracks.Items) != 3 || second.Tracks.Next != "" {
+		t.Fatalf("got %d items and next %q, want the last 3 items", len(second.Tracks.Items), second.Tracks.Next)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(*Client, *fakespotify.Server)
+		kind   ErrorKind
+		status int
+	}{
+		{
+			name: "rate limited",
+			setup: func(c *Client, s *fakespotify.Server) {
+				s.RateLimit(1, time.Minute)
+			},
+			kind:   RateLimitError,
+			status: http.StatusTooManyRequests,
+		},
+		{
+			name: "bad request",
+			setup: func(c *Client, s *fakespotify.Server) {
+				s.Fail(http.StatusBadRequest, "Invalid limit")
+			},
+			kind:   UnknownError,
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "server error",
+			setup: func(c *Client, s *fakespotify.Server) {
+				for range defaultMaxAttempts {
+					s.Fail(http.StatusBadGateway, "Bad gateway")
+				}
+			},
+			kind:   UnknownError,
+			status: http.StatusBadGateway,
+		},
+		{
+			name: "bad credentials",
+			setup: func(c *Client, s *fakespotify.Server) {
+				c.Config.API.ClientSecret = "wrong"
+			},
+			kind:   AuthError,
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "unreachable",
+			setup: func(c *Client, s *fakespotify.Server) {
+				s.Close()
+			},
+			kind: NetworkError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			tt.setup(client, server)
+
+			_, err := client.search(context.Background(), SearchQuery{Q: "nirvana", Type: "track"})
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got error %v, want an *APIError", err)
+			}
+			if apiErr.Kind != tt.kind || apiErr.Status != tt.status {
+				t.Errorf("got %v (%d), want %v (%d)", apiErr.Kind, apiErr.Status, tt.kind, tt.status)
+			}
+		})
+	}
+}
+
+func TestSearchRetryAfterOverBudget(t *testing.T) {
+	client, server := newTestClient(t)
+	server.RateLimit(1, 45*time.Second)
+
+	start := time.Now()
+	_, err := client.search(context.Background(), SearchQuery{Q: "nirvana", Type: "track"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 45*time.Second {
+		t.Fatalf("got %v, want a rate limit error with a 45s Retry-After", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("waited for a Retry-After beyond the retry budget")
+	}
+}
+
+func TestSearchRetries(t *testing.T) {
+	client, server := newTestClient(t)
+	server.RateLimit(2, 0)
+	server.Fail(http.StatusServiceUnavailable, "Service unavailable")
+
+	var events []RetryEvent
+	client.OnRetry = func(e RetryEvent) {
+		events = append(events, e)
+	}
+
+	results, err := client.search(context.Background(), SearchQuery{Q: "nirvana", Type: "track"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.Tracks.Total != 8 {
+		t.Errorf("got %d tracks, want 8", results.Tracks.Total)
+	}
+	if len(events) != 3 {
+		t.Errorf("got %d retries, want 3", len(events))
+	}
+	if calls := server.APICalls(); calls != 4 {
+		t.Errorf("got %d API calls, want 4", calls)
+	}
+}
+
+func TestWithoutRetries(t *testing.T) {
+	client, server := newTestClient(t)
+	server.Fail(http.StatusServiceUnavailable, "Service unavailable")
+
+	_, err := client.search(withoutRetries(context.Background()), SearchQuery{Q: "nirvana", Type: "track"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+		t.Fatalf("got %v, want the 503", err)
+	}
+	if calls := server.APICalls(); calls != 1 {
+		t.Errorf("got %d API calls, want 1", calls)
+	}
+}
+
+func TestPostRetries(t *testing.T) {
+	tests := []struct {
+		name  string
+		fail  func(*fakespotify.Server)
+		calls int
+	}{
+		{"server error", func(s *fakespotify.Server) { s.Fail(http.StatusBadGateway, "Bad gateway") }, 1},
+		{"rate limit", func(s *fakespotify.Server) { s.RateLimit(1, 0) }, 1},
+		{"rate limit with Retry-After", func(s *fakespotify.Server) { s.RateLimit(1, time.Second) }, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newLoggedInClient(t)
+			tt.fail(server)
+
+			// Skipping to the next track is not idempotent.
+			client.skipNext(context.Background(), "")
+
+			if calls := server.APICalls(); calls != tt.calls {
+				t.Errorf("got %d API calls, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestFetchTokenIsCached(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	first, err := client.fetchToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.fetchToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.AccessToken != second.AccessToken {
+		t.Error("got a new token, want the cached one")
+	}
+}
+
+func TestFetchTokenRefreshes(t *testing.T) {
+	client, server := newTestClient(t)
+	if err := os.WriteFile(client.Config.TokenPath, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	login := server.Login()
+	if err := client.storeRefreshToken(login); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each refresh revokes the refresh token it used, so only one of these
+	// may refresh and the others have to pick up what it stored.
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = client.getCurrentUser(context.Background())
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	if calls := server.APICalls(); calls != 5 {
+		t.Errorf("got %d API calls, want 5", calls)
+	}
+
+	token, err := client.readToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.RefreshToken == "" || token.RefreshToken == login {
+		t.Errorf("got refresh token %q, want the one the refresh handed out", token.RefreshToken)
+	}
+	if info, err := os.Stat(client.Config.TokenPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("got %v and %v, want a token file only the user can read", info.Mode(), err)
+	}
+}
+
+func TestFetchTokenKeepsRevokedLogin(t *testing.T) {
+	client, _ := newTestClient(t)
+	if err := client.storeRefreshToken("revoked"); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := client.fetchToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.fetchToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.RefreshToken != "" || first.AccessToken != second.AccessToken {
+		t.Errorf("got %+v then %+v, want the same app-only token", first, second)
+	}
+
+	stored, err := client.readToken()
+	if err != nil || stored.RefreshToken != "revoked" {
+		t.Errorf("got %+v and %v, want the revoked login left for logging in again", stored, err)
+	}
+	if _, err := client.getPlaybackState(context.Background()); !errors.Is(err, errLoginRequired) {
+		t.Errorf("got %v, want errLoginRequired", err)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	shared := &http.Client{Timeout: 5 * time.Second}
+	rt := &http.Transport{}
+	withBoth, err := NewClient(client.Config, WithHTTPClient(shared), WithTransport(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shared.Transport != nil {
+		t.Error("WithTransport changed the client given to WithHTTPClient")
+	}
+	if withBoth.HTTPClient.Transport != rt || withBoth.HTTPClient.Timeout != 5*time.Second {
+		t.Errorf("got transport %v and timeout %s, want rt and the shared client's timeout",
+			withBoth.HTTPClient.Transport, withBoth.HTTPClient.Timeout)
+	}
+
+	for _, api := range []struct{ Timeout, Proxy string }{{Timeout: "soon"}, {Proxy: "://proxy"}} {
+		config := client.Config
+		config.API.Timeout, config.API.Proxy = api.Timeout, api.Proxy
+		if _, err := NewClient(config); err == nil {
+			t.Errorf("got no error for %+v", api)
+		}
+	}
+}
+
+func TestClientProxy(t *testing.T) {
+	client, server := newTestClient(t)
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forward := httputil.NewSingleHostReverseProxy(target)
+	var hosts []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		forward.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	config := client.Config
+	config.API.Proxy = proxy.URL
+	config.API.BaseURL = "http://api.spotify.invalid/v1"
+	config.API.AccountsURL = "http://accounts.spotify.invalid"
+	proxied, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := proxied.search(context.Background(), SearchQuery{Q: "nirvana", Type: "track"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 2 || hosts[0] != "accounts.spotify.invalid" || hosts[1] != "api.spotify.invalid" {
+		t.Errorf("got requests for %v through the proxy, want the token and the search", hosts)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	client, server := newTestClient(t)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	config := client.Config
+	config.API.Timeout = "50ms"
+	config.API.MaxAttempts = 1
+	config.API.BaseURL = slow.URL + "/v1"
+	config.API.AccountsURL = server.AccountsURL()
+	timed, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = timed.search(context.Background(), SearchQuery{Q: "nirvana", Type: "track"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Kind != NetworkError {
+		t.Fatalf("got %v, want a network error", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("took %s, want the request to time out after 50ms", time.Since(start))
+	}
+}